@@ -1,40 +1,271 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"gitlab.ozon.dev/gojhw1/pkg/app"
+	"gitlab.ozon.dev/gojhw1/pkg/audit"
+	"gitlab.ozon.dev/gojhw1/pkg/handler/api"
 	"gitlab.ozon.dev/gojhw1/pkg/handler/commands"
 	"gitlab.ozon.dev/gojhw1/pkg/handler/input"
+	"gitlab.ozon.dev/gojhw1/pkg/notify"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
 	"gitlab.ozon.dev/gojhw1/pkg/repository"
 	"gitlab.ozon.dev/gojhw1/pkg/service"
 	"gitlab.ozon.dev/gojhw1/pkg/storage"
+	"golang.org/x/term"
 )
 
-const storageFile = "./data/storage.json"
+const (
+	storageFile = "./data/storage.json"
+	sqlFile     = "./data/storage.db"
+)
+
+// eventsSuffix - суффикс файла журнала событий заказов рядом с JSON хранилищем
+const eventsSuffix = ".events"
+
+// codesSuffix - суффикс файла выгрузки кодов выдачи для системы уведомлений рядом с хранилищем
+const codesSuffix = ".codes"
+
+const (
+	storageJSON = "json"
+	storageSQL  = "sql"
+)
+
+const (
+	defaultAddr     = ":8080"
+	shutdownTimeout = 10 * time.Second
+)
 
 func main() {
-	repo := repository.NewInMemoryRepository()
-	jsonStorage := storage.NewJSONStorage(storageFile)
+	storageKind := flag.String("storage", storageJSON, "тип хранилища: json или sql")
+	storagePath := flag.String("db", "", "путь к файлу хранилища (по умолчанию "+storageFile+" или "+sqlFile+")")
+	policyPath := flag.String("policy", "", "путь к JSON файлу бизнес-правил (также "+policy.EnvPath+")")
+	format := flag.String("format", "table", "формат вывода списков: table, json, csv или yaml")
+	operator := flag.String("operator", os.Getenv("USER"), "имя оператора, записываемое в историю изменений заказов")
+	point := flag.String("point", "", "ID пункта выдачи из политики (по умолчанию первый из списка points)")
+	flag.Usage = usage
+	flag.Parse()
+
+	pol, err := policy.Resolve(*policyPath)
+	if err != nil {
+		log.Fatalf("ошибка загрузки политики: %v", err)
+	}
 
-	data, err := jsonStorage.Load()
+	path := resolveStoragePath(*storageKind, *storagePath)
+	repo, orderStorage, events, closeRepo, err := openStorage(*storageKind, path)
 	if err != nil {
 		log.Fatalf("ошибка загрузки данных: %v", err)
 	}
-	repo.SetAll(data)
 
-	orderService := service.NewOrderService(repo)
-	cmdHandler := commands.NewHandler(orderService, jsonStorage)
+	opts := []service.Option{
+		service.WithPolicy(pol),
+		service.WithEventLog(events),
+		service.WithCodeOutbox(notify.NewFileOutbox(path + codesSuffix)),
+	}
+	if *operator != "" {
+		opts = append(opts, service.WithActor(*operator))
+	}
+	orderService, err := service.NewOrderService(repo, opts...).ForPoint(selectPoint(*point, pol))
+	if err != nil {
+		log.Fatalf("ошибка параметров запуска: %v", err)
+	}
+	cmdHandler := commands.NewHandler(orderService, orderStorage)
+	if err = cmdHandler.SetFormat(*format); err != nil {
+		log.Fatalf("ошибка параметров запуска: %v", err)
+	}
+
+	switch mode := flag.Arg(0); mode {
+	case "":
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			err = runInteractive(cmdHandler)
+		} else {
+			err = runScript(cmdHandler, nil)
+		}
+	case "exec":
+		err = runExec(cmdHandler, flag.Args()[1:])
+	case "run":
+		err = runScript(cmdHandler, flag.Args()[1:])
+	case "serve":
+		err = runServer(orderService, orderStorage, flag.Args()[1:])
+	default:
+		err = fmt.Errorf("неизвестный режим: %s", mode)
+	}
 
+	closeRepo()
+	if err != nil {
+		log.Fatalf("ошибка работы приложения: %v", err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `Использование:
+  %[1]s [флаги]                                           - интерактивный режим
+  %[1]s [флаги] exec <команда> [аргументы...]             - выполнить одну команду
+  %[1]s [флаги] run [--continue-on-error] [файл сценария] - выполнить команды из файла или stdin
+  %[1]s [флаги] serve [-addr :8080]                       - HTTP API
+
+Если stdin не является терминалом, команды читаются из него построчно, как в run.
+
+Флаги:
+`, os.Args[0])
+	flag.PrintDefaults()
+}
+
+// runInteractive - запускает интерактивный режим с вводом команд
+func runInteractive(cmdHandler *commands.Handler) error {
 	inputHandler, err := input.NewHandler()
 	if err != nil {
-		log.Fatalf("ошибка инициализации readline: %v", err)
+		return fmt.Errorf("ошибка инициализации readline: %w", err)
 	}
 
 	application := app.New(inputHandler, cmdHandler)
 
 	if err = application.StartAndWatch(); err != nil {
 		application.Close()
-		log.Fatalf("ошибка работы приложения: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// runExec - выполняет одну команду
+func runExec(cmdHandler *commands.Handler, args []string) error {
+	if len(args) < 1 {
+		return errors.New("использование: exec <команда> [аргументы...]")
+	}
+
+	application := app.New(nil, cmdHandler)
+
+	return application.Exec(args[0], args[1:])
+}
+
+// runScript - выполняет команды из файла сценария, а если он не указан или равен "-" - из stdin
+func runScript(cmdHandler *commands.Handler, args []string) error {
+	runFlags := flag.NewFlagSet("run", flag.ExitOnError)
+	continueOnError := runFlags.Bool("continue-on-error", false, "продолжать выполнение после ошибки команды")
+	if err := runFlags.Parse(args); err != nil {
+		return err
+	}
+
+	script := io.Reader(os.Stdin)
+	if path := runFlags.Arg(0); path != "" && path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("ошибка открытия сценария: %w", err)
+		}
+		defer file.Close()
+		script = file
+	}
+
+	application := app.New(nil, cmdHandler)
+
+	return application.RunScript(script, *continueOnError)
+}
+
+// runServer - запускает HTTP API и останавливает его по SIGINT/SIGTERM
+func runServer(orderService *service.OrderService, orderStorage storage.OrderStorage, args []string) error {
+	serveFlags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := serveFlags.String("addr", defaultAddr, "адрес HTTP сервера")
+	if err := serveFlags.Parse(args); err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           api.NewHandler(orderService, orderStorage),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("HTTP API слушает %s", *addr)
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// selectPoint - возвращает пункт выдачи из флага, а если он не указан - первый пункт из политики
+func selectPoint(point string, pol *policy.Policy) string {
+	if point == "" && len(pol.Points) > 0 {
+		return pol.Points[0].ID
+	}
+
+	return point
+}
+
+// resolveStoragePath - возвращает путь к хранилищу, а если он не указан - путь по умолчанию для его типа
+func resolveStoragePath(kind, path string) string {
+	if path != "" {
+		return path
+	}
+	if kind == storageSQL {
+		return sqlFile
+	}
+
+	return storageFile
+}
+
+// openStorage - создает репозиторий, хранилище и журнал событий выбранного типа.
+// Для SQL хранилище не нужно: репозиторий сам сохраняет каждое изменение и события в той же базе
+func openStorage(kind, path string) (repository.Repository, storage.OrderStorage, audit.Log, func(), error) {
+	switch kind {
+	case storageJSON:
+		repo := repository.NewInMemoryRepository()
+		jsonStorage := storage.NewJSONStorage(path)
+
+		data, err := jsonStorage.Load()
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		if err = repo.SetAll(data); err != nil {
+			return nil, nil, nil, nil, err
+		}
+
+		events, err := audit.NewFileLog(path + eventsSuffix)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+
+		return repo, jsonStorage, events, func() {}, nil
+	case storageSQL:
+		repo, err := repository.NewSQLRepository(path)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+
+		return repo, nil, repo, func() { repo.Close() }, nil
+	default:
+		return nil, nil, nil, nil, fmt.Errorf("неизвестный тип хранилища: %s", kind)
 	}
 }
@@ -1,20 +1,28 @@
 package app
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
 
 	"github.com/chzyer/readline"
 	"gitlab.ozon.dev/gojhw1/pkg/handler/commands"
 	"gitlab.ozon.dev/gojhw1/pkg/handler/input"
 )
 
+var (
+	ErrCommandFailed = errors.New("команда завершилась с ошибкой")
+)
+
 type App struct {
 	inputHandler *input.Handler
 	cmdHandler   *commands.Handler
 }
 
+// New - создает приложение. inputHandler может быть nil, если используется только неинтерактивный режим
 func New(inputHandler *input.Handler, cmdHandler *commands.Handler) *App {
 	return &App{
 		inputHandler: inputHandler,
@@ -34,7 +42,7 @@ func (a *App) StartAndWatch() error {
 	for {
 		line, err := a.inputHandler.ReadLine()
 		if err != nil {
-			if errors.Is(err, readline.ErrInterrupt) {
+			if errors.Is(err, readline.ErrInterrupt) || errors.Is(err, io.EOF) {
 				fmt.Println("Выход из программы")
 				a.Close()
 				return nil
@@ -48,11 +56,68 @@ func (a *App) StartAndWatch() error {
 		}
 
 		if err = a.cmdHandler.Execute(command, args); err != nil {
+			if errors.Is(err, commands.ErrExit) {
+				fmt.Println("Выход...")
+				a.Close()
+				return nil
+			}
 			log.Printf("ошибка команды %s: %v\n", command, err)
 		}
 	}
 }
 
+// Exec - выполняет одну команду в неинтерактивном режиме
+func (a *App) Exec(command string, args []string) error {
+	a.cmdHandler.SetInteractive(false)
+
+	err := a.cmdHandler.Execute(command, args)
+	if err != nil && !errors.Is(err, commands.ErrExit) {
+		return fmt.Errorf("%w: %s: %w", ErrCommandFailed, command, err)
+	}
+
+	return nil
+}
+
+// RunScript - построчно выполняет команды из r в неинтерактивном режиме.
+// Пустые строки и строки, начинающиеся с #, пропускаются. Команда exit завершает выполнение.
+// Без continueOnError выполнение останавливается на первой ошибке,
+// иначе выполняются все строки, а в конце возвращается ошибка с числом неудачных команд
+func (a *App) RunScript(r io.Reader, continueOnError bool) error {
+	a.cmdHandler.SetInteractive(false)
+
+	scanner := bufio.NewScanner(r)
+	failed := 0
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		command, args := input.ParseLine(scanner.Text())
+		if command == "" {
+			continue
+		}
+
+		err := a.cmdHandler.Execute(command, args)
+		if errors.Is(err, commands.ErrExit) {
+			break
+		}
+		if err == nil {
+			continue
+		}
+
+		if !continueOnError {
+			return fmt.Errorf("%w: строка %d: %s: %w", ErrCommandFailed, lineNum, command, err)
+		}
+		failed++
+		fmt.Fprintf(os.Stderr, "строка %d: ошибка команды %s: %v\n", lineNum, command, err)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения сценария: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: неудачных команд - %d", ErrCommandFailed, failed)
+	}
+
+	return nil
+}
+
 func printWelcome() {
 	fmt.Println(`
         ____              __      ___   ___________    
@@ -0,0 +1,208 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"gitlab.ozon.dev/gojhw1/pkg/handler/commands"
+	"gitlab.ozon.dev/gojhw1/pkg/repository"
+	"gitlab.ozon.dev/gojhw1/pkg/service"
+)
+
+// newTestApp - создает приложение без ввода с клавиатуры над сервисом с репозиторием в памяти
+func newTestApp() (*App, *service.OrderService) {
+	s := service.NewOrderService(repository.NewInMemoryRepository())
+
+	return New(nil, commands.NewHandler(s, nil)), s
+}
+
+// captureOutput - выполняет fn и возвращает то, что команды вывели в stdout и stderr
+func captureOutput(t *testing.T, fn func() error) (stdout, stderr string, err error) {
+	t.Helper()
+
+	read := func(target **os.File) (func() string, error) {
+		r, w, pipeErr := os.Pipe()
+		if pipeErr != nil {
+			return nil, pipeErr
+		}
+		saved := *target
+		*target = w
+
+		done := make(chan string)
+		go func() {
+			data, _ := io.ReadAll(r)
+			done <- string(data)
+		}()
+
+		return func() string {
+			*target = saved
+			w.Close()
+			defer r.Close()
+			return <-done
+		}, nil
+	}
+
+	restoreStdout, pipeErr := read(&os.Stdout)
+	if pipeErr != nil {
+		t.Fatal(pipeErr)
+	}
+	restoreStderr, pipeErr := read(&os.Stderr)
+	if pipeErr != nil {
+		restoreStdout()
+		t.Fatal(pipeErr)
+	}
+
+	err = fn()
+
+	return restoreStdout(), restoreStderr(), err
+}
+
+const (
+	acceptFirst  = "accept_order 1 1 48h 1 100"
+	acceptSecond = "accept_order 2 1 48h 1 100"
+)
+
+func TestRunScript(t *testing.T) {
+	tests := []struct {
+		name            string
+		script          string
+		continueOnError bool
+		// wantErr - ожидаемое сообщение ошибки, пустое - без ошибки
+		wantErr    string
+		wantOrders []int64
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "комментарии и пустые строки пропускаются",
+			script:     "# прием смены\n\n" + acceptFirst + "\n   # отступ перед комментарием\n\t\norder_history --format csv\n",
+			wantOrders: []int64{1},
+			wantStdout: "id,customer_id",
+		},
+		{
+			name:       "остановка на первой ошибке",
+			script:     acceptFirst + "\nunknown_command\n" + acceptSecond + "\n",
+			wantErr:    "строка 2: unknown_command",
+			wantOrders: []int64{1},
+		},
+		{
+			name:       "номер строки учитывает пропущенные строки",
+			script:     "# комментарий\n\n" + acceptFirst + "\n" + acceptFirst + "\n",
+			wantErr:    "строка 4: accept_order",
+			wantOrders: []int64{1},
+		},
+		{
+			name:            "продолжение после ошибки",
+			script:          acceptFirst + "\nunknown_command\n" + acceptSecond + "\n",
+			continueOnError: true,
+			wantErr:         "неудачных команд - 1",
+			wantOrders:      []int64{1, 2},
+			wantStderr:      "строка 2: ошибка команды unknown_command",
+		},
+		{
+			name:       "exit завершает сценарий",
+			script:     acceptFirst + "\nexit\n" + acceptSecond + "\n",
+			wantOrders: []int64{1},
+		},
+		{
+			name:            "exit после ошибки с продолжением",
+			script:          "unknown_command\nexit\n" + acceptSecond + "\n",
+			continueOnError: true,
+			wantErr:         "неудачных команд - 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, s := newTestApp()
+
+			stdout, stderr, err := captureOutput(t, func() error {
+				return a.RunScript(strings.NewReader(tt.script), tt.continueOnError)
+			})
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("ошибка: %v", err)
+			}
+			if tt.wantErr != "" && (!errors.Is(err, ErrCommandFailed) || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("ошибка = %v, ожидалась %v с %q", err, ErrCommandFailed, tt.wantErr)
+			}
+			if !strings.Contains(stdout, tt.wantStdout) {
+				t.Errorf("stdout не содержит %q:\n%s", tt.wantStdout, stdout)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("stderr не содержит %q:\n%s", tt.wantStderr, stderr)
+			}
+
+			orders, err := s.Repo().List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(orders) != len(tt.wantOrders) {
+				t.Fatalf("принято заказов %d, ожидалось %v", len(orders), tt.wantOrders)
+			}
+			for _, id := range tt.wantOrders {
+				if _, err = s.Repo().FindByID(id); err != nil {
+					t.Errorf("заказ %d: %v", id, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRunScriptReadError(t *testing.T) {
+	a, _ := newTestApp()
+	readErr := errors.New("диск недоступен")
+
+	_, _, err := captureOutput(t, func() error {
+		return a.RunScript(iotest.ErrReader(readErr), false)
+	})
+	if !errors.Is(err, readErr) {
+		t.Errorf("ошибка = %v, ожидалась %v", err, readErr)
+	}
+}
+
+func TestExec(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		wantErr error
+	}{
+		{name: "успешная команда", command: "accept_order", args: strings.Fields(acceptFirst)[1:]},
+		{name: "неизвестная команда", command: "unknown_command", wantErr: ErrCommandFailed},
+		{name: "неверные аргументы", command: "accept_order", args: []string{"1"}, wantErr: ErrCommandFailed},
+		{name: "exit не является ошибкой", command: "exit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := newTestApp()
+
+			_, _, err := captureOutput(t, func() error {
+				return a.Exec(tt.command, tt.args)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ошибка = %v, ожидалась %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecIsNonInteractive(t *testing.T) {
+	a, s := newTestApp()
+	if _, _, err := captureOutput(t, func() error { return a.Exec("accept_order", strings.Fields(acceptFirst)[1:]) }); err != nil {
+		t.Fatal(err)
+	}
+
+	// без --yes неинтерактивная очистка не ждет подтверждения и ничего не удаляет
+	_, _, err := captureOutput(t, func() error { return a.Exec("clear_db", nil) })
+	if !errors.Is(err, ErrCommandFailed) {
+		t.Errorf("ошибка = %v, ожидалась %v", err, ErrCommandFailed)
+	}
+	if _, err = s.Repo().FindByID(1); err != nil {
+		t.Errorf("заказ удален без подтверждения: %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+var (
+	ErrReadLog  = errors.New("ошибка чтения журнала событий")
+	ErrWriteLog = errors.New("ошибка записи журнала событий")
+)
+
+// Log - журнал событий заказов, в который можно только дописывать
+type Log interface {
+	// Append - дописывает события в журнал, присваивая им последовательные ID
+	Append(events ...model.OrderEvent) error
+	// ListByOrder - возвращает события заказа в порядке записи
+	ListByOrder(orderID int64) ([]model.OrderEvent, error)
+}
+
+// MemoryLog - журнал событий в памяти
+type MemoryLog struct {
+	mu     sync.RWMutex
+	events []model.OrderEvent
+}
+
+// NewMemoryLog - создает пустой журнал событий в памяти
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{}
+}
+
+// Append - дописывает события в журнал
+func (l *MemoryLog) Append(events ...model.OrderEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, event := range events {
+		event.ID = int64(len(l.events)) + 1
+		l.events = append(l.events, event)
+	}
+
+	return nil
+}
+
+// ListByOrder - возвращает события заказа
+func (l *MemoryLog) ListByOrder(orderID int64) ([]model.OrderEvent, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var result []model.OrderEvent
+	for _, event := range l.events {
+		if event.OrderID == orderID {
+			result = append(result, event)
+		}
+	}
+
+	return result, nil
+}
+
+// FileLog - журнал событий в файле: по одному JSON объекту на строку
+type FileLog struct {
+	path string
+
+	mu     sync.Mutex
+	lastID int64
+}
+
+// NewFileLog - открывает журнал событий в файле, создавая его при необходимости
+func NewFileLog(path string) (*FileLog, error) {
+	l := &FileLog{path: path}
+
+	err := l.scan(func(event model.OrderEvent) {
+		l.lastID = max(l.lastID, event.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = l.terminateTail(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Append - дописывает события в конец файла и сбрасывает их на диск
+func (l *FileLog) Append(events ...model.OrderEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteLog, err)
+	}
+	defer file.Close()
+
+	buf := bufio.NewWriter(file)
+	encoder := json.NewEncoder(buf)
+	lastID := l.lastID
+	for _, event := range events {
+		lastID++
+		event.ID = lastID
+		if err = encoder.Encode(event); err != nil {
+			return fmt.Errorf("%w: %w", ErrWriteLog, err)
+		}
+	}
+
+	if err = buf.Flush(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteLog, err)
+	}
+	if err = file.Sync(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteLog, err)
+	}
+	l.lastID = lastID
+
+	return nil
+}
+
+// ListByOrder - возвращает события заказа
+func (l *FileLog) ListByOrder(orderID int64) ([]model.OrderEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var result []model.OrderEvent
+	err := l.scan(func(event model.OrderEvent) {
+		if event.OrderID == orderID {
+			result = append(result, event)
+		}
+	})
+
+	return result, err
+}
+
+// terminateTail - завершает переводом строки недописанную при сбое последнюю запись,
+// чтобы следующие события не склеились с ней
+func (l *FileLog) terminateTail() error {
+	file, err := os.OpenFile(l.path, os.O_RDWR, 0644)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("%w: %w", ErrWriteLog, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+
+	last := make([]byte, 1)
+	if _, err = file.ReadAt(last, info.Size()-1); err != nil {
+		return fmt.Errorf("%w: %w", ErrReadLog, err)
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+
+	if _, err = file.WriteAt([]byte{'\n'}, info.Size()); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteLog, err)
+	}
+
+	return file.Sync()
+}
+
+// scan - последовательно читает события из файла. Недописанная последняя строка пропускается
+func (l *FileLog) scan(fn func(event model.OrderEvent)) error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("%w: %w", ErrReadLog, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event model.OrderEvent
+		if err = json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		fn(event)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrReadLog, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+var testAt = time.Date(2030, 2, 20, 12, 0, 0, 0, time.UTC)
+
+func testEvent(orderID int64, from, to model.OrderState) model.OrderEvent {
+	return model.OrderEvent{OrderID: orderID, At: testAt, Actor: "ivanov", FromState: from, ToState: to, Command: "test"}
+}
+
+// eventIDs - возвращает ID событий в порядке следования
+func eventIDs(events []model.OrderEvent) []int64 {
+	ids := make([]int64, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+
+	return ids
+}
+
+func TestLogs(t *testing.T) {
+	logs := map[string]func(t *testing.T) Log{
+		"память": func(_ *testing.T) Log { return NewMemoryLog() },
+		"файл": func(t *testing.T) Log {
+			l, err := NewFileLog(filepath.Join(t.TempDir(), "storage.json.events"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return l
+		},
+	}
+
+	for name, newLog := range logs {
+		t.Run(name, func(t *testing.T) {
+			l := newLog(t)
+			if err := l.Append(testEvent(1, model.StateNew, model.StateAccepted), testEvent(2, model.StateNew, model.StateAccepted)); err != nil {
+				t.Fatal(err)
+			}
+			if err := l.Append(); err != nil {
+				t.Fatal(err)
+			}
+			if err := l.Append(testEvent(1, model.StateAccepted, model.StateDelivered)); err != nil {
+				t.Fatal(err)
+			}
+
+			// события заказа идут в порядке записи, ID сквозные для всего журнала
+			events, err := l.ListByOrder(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []model.OrderEvent{testEvent(1, model.StateNew, model.StateAccepted), testEvent(1, model.StateAccepted, model.StateDelivered)}
+			want[0].ID, want[1].ID = 1, 3
+			if !reflect.DeepEqual(events, want) {
+				t.Errorf("события заказа 1 = %+v, ожидалось %+v", events, want)
+			}
+
+			if events, err = l.ListByOrder(3); err != nil || len(events) != 0 {
+				t.Errorf("события неизвестного заказа = %+v, %v", events, err)
+			}
+		})
+	}
+}
+
+func TestFileLogReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.json.events")
+	l, err := NewFileLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = l.Append(testEvent(1, model.StateNew, model.StateAccepted), testEvent(2, model.StateNew, model.StateAccepted)); err != nil {
+		t.Fatal(err)
+	}
+
+	// после перезапуска журнал читает прежние события и продолжает нумерацию
+	reopened, err := NewFileLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = reopened.Append(testEvent(2, model.StateAccepted, model.StateDelivered)); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := reopened.ListByOrder(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := eventIDs(events); !reflect.DeepEqual(got, []int64{2, 3}) {
+		t.Errorf("ID событий заказа 2 = %v, ожидалось [2 3]", got)
+	}
+	if events[1].ToState != model.StateDelivered || events[1].Actor != "ivanov" || !events[1].At.Equal(testAt) {
+		t.Errorf("событие после перезапуска = %+v", events[1])
+	}
+}
+
+func TestFileLogTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.json.events")
+	l, err := NewFileLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = l.Append(testEvent(1, model.StateNew, model.StateAccepted)); err != nil {
+		t.Fatal(err)
+	}
+
+	// сбой во время записи оставил недописанную строку
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = file.WriteString(`{"id":2,"order_id":1,"to_st`); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	reopened, err := NewFileLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = reopened.Append(testEvent(1, model.StateAccepted, model.StateDelivered)); err != nil {
+		t.Fatal(err)
+	}
+
+	// недописанная строка пропускается, а новое событие не склеивается с ней
+	events, err := reopened.ListByOrder(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := eventIDs(events); !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Errorf("ID событий = %v, ожидалось [1 2]", got)
+	}
+}
+
+func TestFileLogMissingFile(t *testing.T) {
+	l, err := NewFileLog(filepath.Join(t.TempDir(), "storage.json.events"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := l.ListByOrder(1)
+	if err != nil || len(events) != 0 {
+		t.Errorf("события пустого журнала = %+v, %v", events, err)
+	}
+}
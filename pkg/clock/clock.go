@@ -0,0 +1,89 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock - источник текущего времени
+type Clock interface {
+	Now() time.Time
+}
+
+// Real - системные часы
+type Real struct{}
+
+// Now - возвращает текущее системное время
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Fake - управляемые часы: время стоит на месте, пока его явно не изменят.
+// Безопасны для конкурентного использования
+type Fake struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+// NewFake - создает часы, показывающие указанное время
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now - возвращает установленное время
+func (f *Fake) Now() time.Time {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.now
+}
+
+// Set - устанавливает текущее время
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = now
+}
+
+// Advance - сдвигает текущее время на d
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+}
+
+// Settable - часы, источник времени которых можно заменить во время работы.
+// Копии сервиса разделяют один экземпляр, поэтому замена видна им всем.
+// Безопасны для конкурентного использования
+type Settable struct {
+	mu     sync.RWMutex
+	source Clock
+}
+
+// NewSettable - создает часы, показывающие время источника source
+func NewSettable(source Clock) *Settable {
+	return &Settable{source: source}
+}
+
+// Now - возвращает время текущего источника
+func (s *Settable) Now() time.Time {
+	return s.Source().Now()
+}
+
+// Source - возвращает текущий источник времени
+func (s *Settable) Source() Clock {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.source
+}
+
+// Set - заменяет источник времени
+func (s *Settable) Set(source Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.source = source
+}
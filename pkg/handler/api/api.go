@@ -0,0 +1,672 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/packaging"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+	"gitlab.ozon.dev/gojhw1/pkg/service"
+	"gitlab.ozon.dev/gojhw1/pkg/storage"
+)
+
+var (
+	ErrInvalidBody      = errors.New("неверный формат тела запроса")
+	ErrInvalidParameter = errors.New("неверный параметр запроса")
+)
+
+const defaultPageSize = 5
+
+// operatorHeader - заголовок с именем оператора, от имени которого выполняется запрос
+const operatorHeader = "X-Operator"
+
+const defaultOperator = "api"
+
+// pointHeader - заголовок с ID пункта выдачи, к которому относится запрос.
+// Без него запрос выполняется для пункта, выбранного при запуске
+const pointHeader = "X-Point"
+
+// Handler - HTTP обработчик, предоставляющий операции OrderService в виде JSON API
+type Handler struct {
+	service *service.OrderService
+	storage storage.OrderStorage
+	mux     *http.ServeMux
+
+	saveMu sync.Mutex
+}
+
+// acceptOrderRequest - тело запроса на прием заказа
+type acceptOrderRequest struct {
+	ID          int64    `json:"id"`
+	CustomerID  int64    `json:"customer_id"`
+	DeadlineAt  string   `json:"deadline_at"`
+	Weight      float64  `json:"weight"`
+	Cost        float64  `json:"cost"`
+	PackageType string   `json:"package_type,omitempty"`
+	Wrappers    []string `json:"wrappers,omitempty"`
+	// Dimensions - габариты заказа в см
+	Dimensions *model.Dimensions `json:"dimensions,omitempty"`
+	Fragile    bool              `json:"fragile,omitempty"`
+	PromoCode  string            `json:"promo_code,omitempty"`
+	// Wrapper - единственная обертка, поддерживается для совместимости с прежними клиентами
+	Wrapper string `json:"wrapper,omitempty"`
+}
+
+// extendRequest - тело запроса на продление срока хранения: длительность или новый срок
+type extendRequest struct {
+	Extension string `json:"extension"`
+}
+
+// transferRequest - тело запроса на перемещение заказа в другой пункт выдачи
+type transferRequest struct {
+	Point string `json:"point"`
+}
+
+// orderIDsRequest - тело запроса на возврат нескольких заказов
+type orderIDsRequest struct {
+	OrderIDs []int64 `json:"order_ids"`
+}
+
+// handoutRequest - тело запроса на выдачу заказов по кодам выдачи
+type handoutRequest struct {
+	Orders []struct {
+		ID   int64  `json:"id"`
+		Code string `json:"code"`
+	} `json:"orders"`
+}
+
+// pageResponse - страница списка заказов
+type pageResponse struct {
+	Orders   []model.Order `json:"orders"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+	Total    int           `json:"total"`
+}
+
+// NewHandler - создает HTTP обработчик.
+// storage может быть nil, если репозиторий сервиса сам сохраняет данные (например, SQL)
+func NewHandler(service *service.OrderService, storage storage.OrderStorage) *Handler {
+	h := &Handler{
+		service: service,
+		storage: storage,
+		mux:     http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("POST /orders", h.acceptOrder)
+	h.mux.HandleFunc("POST /orders/batch", h.acceptOrders)
+	h.mux.HandleFunc("GET /orders/history", h.orderHistory)
+	h.mux.HandleFunc("GET /orders/{id}", h.getOrder)
+	h.mux.HandleFunc("GET /orders/{id}/events", h.orderEvents)
+	h.mux.HandleFunc("GET /orders/{id}/price-breakdown", h.priceBreakdown)
+	h.mux.HandleFunc("POST /orders/{id}/return-to-courier", h.returnToCourier)
+	h.mux.HandleFunc("POST /orders/{id}/transfer", h.transferOrder)
+	h.mux.HandleFunc("POST /orders/{id}/extend", h.extendStorage)
+	h.mux.HandleFunc("POST /orders/{id}/pickup-code", h.reissueCode)
+	h.mux.HandleFunc("GET /customers/{customerID}/orders", h.listOrders)
+	h.mux.HandleFunc("POST /customers/{customerID}/handout", h.handout)
+	h.mux.HandleFunc("POST /customers/{customerID}/returns", h.customerReturn)
+	h.mux.HandleFunc("GET /returns", h.listReturns)
+	h.mux.HandleFunc("GET /capacity", h.capacity)
+	h.mux.HandleFunc("GET /points", h.points)
+	h.mux.HandleFunc("GET /packages", h.packages)
+	h.mux.HandleFunc("GET /packages/suggest", h.suggestPackage)
+
+	return h
+}
+
+// ServeHTTP - обрабатывает HTTP запрос. Запрос с неизвестным пунктом выдачи отклоняется до обработки
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if point := r.Header.Get(pointHeader); point != "" {
+		if _, err := h.service.ForPoint(point); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	h.mux.ServeHTTP(w, r)
+}
+
+// acceptOrder - принимает заказ от курьера
+func (h *Handler) acceptOrder(w http.ResponseWriter, r *http.Request) {
+	var req acceptOrderRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	deadline, err := h.serviceFor(r).ParseDeadline(req.DeadlineAt)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	packageType, wrappers := parsePackaging(req.PackageType, req.Wrapper, req.Wrappers)
+	opts := service.AcceptOptions{Dimensions: req.Dimensions, Fragile: req.Fragile, PromoCode: req.PromoCode}
+	if err = h.serviceFor(r).AcceptOrder(req.ID, req.CustomerID, deadline, req.Weight, req.Cost, packageType, wrappers, opts); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err = h.saveData(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	order, err := h.serviceFor(r).Repo().FindByID(req.ID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, order)
+}
+
+// acceptOrders - принимает заказы из тела запроса и возвращает отчет по каждой записи.
+// Параметры: mode - atomic (по умолчанию) или best-effort; format - json, ndjson или csv;
+// delimiter и columns - разделитель и сопоставление столбцов CSV
+func (h *Handler) acceptOrders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	mode, err := service.ParseBatchMode(query.Get("mode"))
+	if err != nil {
+		writeError(w, fmt.Errorf("%w: %w", ErrInvalidParameter, err))
+		return
+	}
+	opts, err := service.ParseImportOptions(query.Get("format"), query.Get("delimiter"), query.Get("columns"))
+	if err != nil {
+		writeError(w, fmt.Errorf("%w: %w", ErrInvalidParameter, err))
+		return
+	}
+
+	report, err := h.serviceFor(r).AcceptOrdersFromReader(r.Body, mode, opts)
+	if report == nil {
+		writeError(w, err)
+		return
+	}
+
+	if report.Accepted > 0 {
+		if saveErr := h.saveData(); saveErr != nil {
+			writeError(w, saveErr)
+			return
+		}
+	}
+
+	status := http.StatusOK
+	if err != nil {
+		status = statusFromError(err)
+	}
+	writeJSON(w, status, report)
+}
+
+// getOrder - возвращает заказ по ID
+func (h *Handler) getOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt(r, "id")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	order, err := h.serviceFor(r).Repo().FindByID(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// orderEvents - возвращает историю изменений заказа
+func (h *Handler) orderEvents(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt(r, "id")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	events, err := h.serviceFor(r).OrderEvents(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if events == nil {
+		events = []model.OrderEvent{}
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+// priceBreakdown - возвращает, из чего сложилась стоимость заказа
+func (h *Handler) priceBreakdown(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt(r, "id")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	breakdown, err := h.serviceFor(r).PriceBreakdown(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, breakdown)
+}
+
+// returnToCourier - возвращает заказ курьеру
+func (h *Handler) returnToCourier(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt(r, "id")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err = h.serviceFor(r).ReturnOrderToCourier(id); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err = h.saveData(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reissueCode - выпускает новый код выдачи заказа и передает его в выгрузку для уведомления клиента
+func (h *Handler) reissueCode(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt(r, "id")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err = h.serviceFor(r).ReissuePickupCode(id); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err = h.saveData(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// extendStorage - продлевает срок хранения заказа и возвращает обновленный заказ
+func (h *Handler) extendStorage(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt(r, "id")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req extendRequest
+	if err = decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	order, err := h.serviceFor(r).ExtendStorage(id, req.Extension)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err = h.saveData(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// transferOrder - перемещает заказ в другой пункт выдачи и возвращает его в пункте назначения
+func (h *Handler) transferOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt(r, "id")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req transferRequest
+	if err = decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	svc := h.serviceFor(r)
+	if err = svc.TransferOrder(id, req.Point); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err = h.saveData(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	destination, err := svc.ForPoint(req.Point)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	order, err := destination.Repo().FindByID(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// handout - выдает клиенту заказы по кодам выдачи: либо все, либо ни один.
+// Неверные попытки ввода кода сохраняются, даже если выдача не состоялась
+func (h *Handler) handout(w http.ResponseWriter, r *http.Request) {
+	customerID, err := pathInt(r, "customerID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req handoutRequest
+	if err = decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(req.Orders) == 0 {
+		writeError(w, fmt.Errorf("%w: orders не может быть пустым", ErrInvalidBody))
+		return
+	}
+
+	handouts := make([]service.Handout, 0, len(req.Orders))
+	for _, order := range req.Orders {
+		handouts = append(handouts, service.Handout{OrderID: order.ID, Code: order.Code})
+	}
+
+	deliverErr := h.serviceFor(r).DeliverOrders(handouts, customerID)
+	if err = h.saveData(); err != nil {
+		writeError(w, err)
+		return
+	}
+	if deliverErr != nil {
+		writeError(w, deliverErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// customerReturn - принимает возврат заказов клиента: либо все, либо ни одного
+func (h *Handler) customerReturn(w http.ResponseWriter, r *http.Request) {
+	h.processCustomer(w, r, h.serviceFor(r).ProcessReturnOrders)
+}
+
+func (h *Handler) processCustomer(w http.ResponseWriter, r *http.Request, process func(ids []int64, customerID int64) error) {
+	customerID, err := pathInt(r, "customerID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req orderIDsRequest
+	if err = decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(req.OrderIDs) == 0 {
+		writeError(w, fmt.Errorf("%w: order_ids не может быть пустым", ErrInvalidBody))
+		return
+	}
+
+	if err = process(req.OrderIDs, customerID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err = h.saveData(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listOrders - возвращает заказы клиента. Параметры: last - только N последних, pvz - только заказы в ПВЗ
+func (h *Handler) listOrders(w http.ResponseWriter, r *http.Request) {
+	customerID, err := pathInt(r, "customerID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	lastN, err := queryInt(r, "last", 0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	filterPVZ, err := queryBool(r, "pvz")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s := h.serviceFor(r)
+	orders, err := s.ListOrders(customerID, lastN, filterPVZ)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.Listing(orders))
+}
+
+// listReturns - возвращает страницу списка возвратов. Параметры: page (с 1), page_size
+func (h *Handler) listReturns(w http.ResponseWriter, r *http.Request) {
+	page, err := queryInt(r, "page", 1)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	pageSize, err := queryInt(r, "page_size", defaultPageSize)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if page <= 0 || pageSize <= 0 {
+		writeError(w, fmt.Errorf("%w: page и page_size должны быть больше 0", ErrInvalidParameter))
+		return
+	}
+
+	returns, err := h.serviceFor(r).ListReturns()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	start := min((page-1)*pageSize, len(returns))
+	end := min(start+pageSize, len(returns))
+	writeJSON(w, http.StatusOK, pageResponse{
+		Orders:   returns[start:end],
+		Page:     page,
+		PageSize: pageSize,
+		Total:    len(returns),
+	})
+}
+
+// orderHistory - возвращает историю заказов от новых к старым
+func (h *Handler) orderHistory(w http.ResponseWriter, r *http.Request) {
+	orders, err := h.serviceFor(r).OrderHistory()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, orders)
+}
+
+// capacityResponse - загрузка ПВЗ и лимиты вместимости
+type capacityResponse struct {
+	Usage  service.Utilization `json:"usage"`
+	Limits policy.CapacitySpec `json:"limits"`
+}
+
+// capacity - возвращает текущую загрузку ПВЗ и лимиты вместимости
+func (h *Handler) capacity(w http.ResponseWriter, r *http.Request) {
+	usage, err := h.serviceFor(r).Utilization()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, capacityResponse{Usage: usage, Limits: h.service.Policy().Capacity})
+}
+
+// points - возвращает пункты выдачи из политики с их загрузкой
+func (h *Handler) points(w http.ResponseWriter, _ *http.Request) {
+	points, err := h.service.Points()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, points)
+}
+
+// packagesResponse - типы упаковки и обертки из реестра упаковки
+type packagesResponse struct {
+	Packages []packaging.Package `json:"packages"`
+	Wrappers []packaging.Wrapper `json:"wrappers"`
+}
+
+// packages - возвращает доступные типы упаковки и обертки
+func (h *Handler) packages(w http.ResponseWriter, _ *http.Request) {
+	registry := h.service.Packaging()
+
+	writeJSON(w, http.StatusOK, packagesResponse{Packages: registry.Packages(), Wrappers: registry.Wrappers()})
+}
+
+// suggestPackage - подбирает самую дешевую подходящую упаковку для заказа весом weight
+// с габаритами dims ("30x20x10", необязательно)
+func (h *Handler) suggestPackage(w http.ResponseWriter, r *http.Request) {
+	weight, err := strconv.ParseFloat(r.URL.Query().Get("weight"), 64)
+	if err != nil {
+		writeError(w, fmt.Errorf("%w: weight: %w", ErrInvalidParameter, err))
+		return
+	}
+
+	var dimensions *model.Dimensions
+	if raw := r.URL.Query().Get("dims"); raw != "" {
+		d, err := model.ParseDimensions(raw)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		dimensions = &d
+	}
+
+	suggestion, err := h.service.SuggestPackage(weight, dimensions)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, suggestion)
+}
+
+// serviceFor - возвращает сервис пункта выдачи из заголовка X-Point,
+// записывающий события от имени оператора из заголовка X-Operator
+func (h *Handler) serviceFor(r *http.Request) *service.OrderService {
+	operator := r.Header.Get(operatorHeader)
+	if operator == "" {
+		operator = defaultOperator
+	}
+
+	scoped := h.service.ForActor(operator)
+	// неизвестный пункт отклоняется в ServeHTTP
+	if point := r.Header.Get(pointHeader); point != "" {
+		if pointService, err := scoped.ForPoint(point); err == nil {
+			scoped = pointService
+		}
+	}
+
+	return scoped
+}
+
+// saveData - сохраняет состояние репозитория в хранилище.
+// Сохранения выполняются последовательно, чтобы более старый снимок не перезаписал новый
+func (h *Handler) saveData() error {
+	if h.storage == nil {
+		return nil
+	}
+
+	h.saveMu.Lock()
+	defer h.saveMu.Unlock()
+
+	data, err := h.service.Repo().GetAll()
+	if err != nil {
+		return err
+	}
+
+	return h.storage.Save(data)
+}
+
+func pathInt(r *http.Request, name string) (int64, error) {
+	value, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s: %w", ErrInvalidParameter, name, err)
+	}
+
+	return value, nil
+}
+
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s: %w", ErrInvalidParameter, name, err)
+	}
+
+	return value, nil
+}
+
+func queryBool(r *http.Request, name string) (bool, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return false, nil
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s: %w", ErrInvalidParameter, name, err)
+	}
+
+	return value, nil
+}
+
+// parsePackaging - разбирает упаковку и обертки запроса. Прежнее поле wrapper идет первым в списке оберток
+func parsePackaging(packageType, wrapper string, wrappers []string) (*model.PackageType, []model.WrapperType) {
+	var pt *model.PackageType
+	if packageType != "" {
+		p := model.PackageType(packageType)
+		pt = &p
+	}
+
+	if wrapper != "" {
+		wrappers = append([]string{wrapper}, wrappers...)
+	}
+	var wt []model.WrapperType
+	for _, w := range wrappers {
+		wt = append(wt, model.WrapperType(w))
+	}
+
+	return pt, wt
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitlab.ozon.dev/gojhw1/pkg/notify"
+	"gitlab.ozon.dev/gojhw1/pkg/repository"
+	"gitlab.ozon.dev/gojhw1/pkg/service"
+)
+
+func TestResponsesOmitPickupCodeHash(t *testing.T) {
+	outbox := notify.NewMemoryOutbox()
+	s := service.NewOrderService(repository.NewInMemoryRepository(), service.WithCodeOutbox(outbox))
+	h := NewHandler(s, nil)
+
+	do := func(t *testing.T, method, path, body string, wantStatus int) string {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		if rec.Code != wantStatus {
+			t.Fatalf("%s %s: статус %d, ожидался %d: %s", method, path, rec.Code, wantStatus, rec.Body)
+		}
+
+		return rec.Body.String()
+	}
+
+	order := `{"customer_id": 1, "deadline_at": "48h", "weight": 1, "cost": 100, "package_type": "box", "id": `
+	steps := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPost, "/orders", order + "1}", http.StatusCreated},
+		{http.MethodPost, "/orders", order + "2}", http.StatusCreated},
+		{http.MethodGet, "/orders/2", "", http.StatusOK},
+		{http.MethodPost, "/orders/2/extend", `{"extension": "24h"}`, http.StatusOK},
+		{http.MethodGet, "/orders/history", "", http.StatusOK},
+		{http.MethodGet, "/customers/1/orders", "", http.StatusOK},
+		{http.MethodGet, "/returns", "", http.StatusOK},
+	}
+
+	for _, step := range steps {
+		t.Run(step.method+" "+step.path, func(t *testing.T) {
+			body := do(t, step.method, step.path, step.body, step.status)
+			if strings.Contains(body, "pickup_code_hash") {
+				t.Errorf("ответ содержит хеш кода выдачи: %s", body)
+			}
+		})
+	}
+
+	// хеш хранится в заказе, то есть ответы без него не объясняются пустым значением
+	stored, err := s.Repo().FindByID(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.PickupCodeHash == "" || len(outbox.Notices()) == 0 {
+		t.Fatal("у принятого заказа нет кода выдачи")
+	}
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+	"gitlab.ozon.dev/gojhw1/pkg/repository"
+	"gitlab.ozon.dev/gojhw1/pkg/service"
+)
+
+// testRequest - запрос к API и ожидаемый статус ответа
+type testRequest struct {
+	name       string
+	method     string
+	path       string
+	body       string
+	header     map[string]string
+	wantStatus int
+}
+
+func newTestHandler(opts ...service.Option) *Handler {
+	return NewHandler(service.NewOrderService(repository.NewInMemoryRepository(), opts...), nil)
+}
+
+// serve - выполняет запросы по порядку и проверяет статусы ответов
+func serve(t *testing.T, h *Handler, requests []testRequest) {
+	t.Helper()
+
+	for _, req := range requests {
+		t.Run(req.name, func(t *testing.T) {
+			r := httptest.NewRequest(req.method, req.path, strings.NewReader(req.body))
+			for name, value := range req.header {
+				r.Header.Set(name, value)
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+			if rec.Code != req.wantStatus {
+				t.Errorf("%s %s: статус %d, ожидался %d: %s", req.method, req.path, rec.Code, req.wantStatus, rec.Body)
+			}
+		})
+	}
+}
+
+func acceptBody(id, customerID int64) string {
+	return fmt.Sprintf(`{"id": %d, "customer_id": %d, "deadline_at": "48h", "weight": 1, "cost": 100}`, id, customerID)
+}
+
+func TestRoutes(t *testing.T) {
+	serve(t, newTestHandler(), []testRequest{
+		{name: "прием заказа", method: http.MethodPost, path: "/orders", body: acceptBody(1, 1), wantStatus: http.StatusCreated},
+		{name: "повторный прием", method: http.MethodPost, path: "/orders", body: acceptBody(1, 1), wantStatus: http.StatusConflict},
+		{name: "неизвестное поле в теле", method: http.MethodPost, path: "/orders", body: `{"id": 2, "price": 1}`, wantStatus: http.StatusBadRequest},
+		{name: "отрицательный вес", method: http.MethodPost, path: "/orders",
+			body: `{"id": 2, "customer_id": 1, "deadline_at": "48h", "weight": -1, "cost": 100}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "заказ", method: http.MethodGet, path: "/orders/1", wantStatus: http.StatusOK},
+		{name: "неизвестный заказ", method: http.MethodGet, path: "/orders/99", wantStatus: http.StatusNotFound},
+		{name: "нечисловой ID", method: http.MethodGet, path: "/orders/abc", wantStatus: http.StatusBadRequest},
+		{name: "неподдерживаемый метод", method: http.MethodDelete, path: "/orders/1", wantStatus: http.StatusMethodNotAllowed},
+		{name: "заказы клиента", method: http.MethodGet, path: "/customers/1/orders?last=1", wantStatus: http.StatusOK},
+		{name: "неверный параметр списка", method: http.MethodGet, path: "/customers/1/orders?pvz=maybe", wantStatus: http.StatusBadRequest},
+		{name: "возврат чужого заказа", method: http.MethodPost, path: "/customers/2/returns", body: `{"order_ids": [1]}`, wantStatus: http.StatusForbidden},
+		{name: "возврат невыданного заказа", method: http.MethodPost, path: "/customers/1/returns", body: `{"order_ids": [1]}`, wantStatus: http.StatusConflict},
+		{name: "возврат курьеру до истечения срока", method: http.MethodPost, path: "/orders/1/return-to-courier", wantStatus: http.StatusConflict},
+		{name: "список возвратов", method: http.MethodGet, path: "/returns?page=1&page_size=2", wantStatus: http.StatusOK},
+		{name: "неверная страница", method: http.MethodGet, path: "/returns?page=0", wantStatus: http.StatusBadRequest},
+		{name: "история", method: http.MethodGet, path: "/orders/history", wantStatus: http.StatusOK},
+	})
+}
+
+func TestPointHeader(t *testing.T) {
+	p := policy.Default()
+	p.Points = []model.PickupPoint{{ID: "msk-1"}, {ID: "msk-2"}}
+	inPoint := func(point string) map[string]string { return map[string]string{pointHeader: point} }
+
+	// без заголовка запрос видит заказы всех пунктов
+	serve(t, newTestHandler(service.WithPolicy(p)), []testRequest{
+		{name: "неизвестный пункт", method: http.MethodGet, path: "/orders/history", header: inPoint("spb-1"), wantStatus: http.StatusNotFound},
+		{name: "прием во втором пункте", method: http.MethodPost, path: "/orders", body: acceptBody(1, 1), header: inPoint("msk-2"), wantStatus: http.StatusCreated},
+		{name: "заказ другого пункта", method: http.MethodGet, path: "/orders/1", header: inPoint("msk-1"), wantStatus: http.StatusNotFound},
+		{name: "заказ своего пункта", method: http.MethodGet, path: "/orders/1", header: inPoint("msk-2"), wantStatus: http.StatusOK},
+		{name: "без заголовка", method: http.MethodGet, path: "/orders/1", wantStatus: http.StatusOK},
+		{name: "тот же номер в другом пункте", method: http.MethodPost, path: "/orders", body: acceptBody(1, 1), header: inPoint("msk-1"), wantStatus: http.StatusConflict},
+	})
+}
+
+func TestCustomPackageTypes(t *testing.T) {
+	p := policy.Default()
+	p.Packages["envelope"] = policy.PackageSpec{Cost: 2, MaxWeight: 0.5}
+	p.Wrappers["bubble"] = policy.WrapperSpec{Cost: 3}
+	s := service.NewOrderService(repository.NewInMemoryRepository(), service.WithPolicy(p))
+	order := func(id int64, weight float64, packaging string) string {
+		return fmt.Sprintf(`{"id": %d, "customer_id": 1, "deadline_at": "48h", "weight": %v, "cost": 100, %s}`, id, weight, packaging)
+	}
+
+	// типы из политики принимаются так же, как типы по умолчанию, и видны в списке упаковки
+	serve(t, NewHandler(s, nil), []testRequest{
+		{name: "тип из политики", method: http.MethodPost, path: "/orders",
+			body: order(1, 0.4, `"package_type": "envelope", "wrapper": "bubble"`), wantStatus: http.StatusCreated},
+		{name: "ограничение веса из политики", method: http.MethodPost, path: "/orders",
+			body: order(2, 1, `"package_type": "envelope"`), wantStatus: http.StatusUnprocessableEntity},
+		{name: "незарегистрированный тип", method: http.MethodPost, path: "/orders",
+			body: order(3, 1, `"package_type": "pallet"`), wantStatus: http.StatusUnprocessableEntity},
+		{name: "незарегистрированная обертка", method: http.MethodPost, path: "/orders",
+			body: order(4, 0.4, `"package_type": "envelope", "wrapper": "rope"`), wantStatus: http.StatusUnprocessableEntity},
+		{name: "список упаковки", method: http.MethodGet, path: "/packages", wantStatus: http.StatusOK},
+	})
+
+	accepted, err := s.Repo().FindByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accepted.PackagingCost != 5 || accepted.Cost != 105 {
+		t.Errorf("стоимость упаковки %v, стоимость %v, ожидалось 5 и 105", accepted.PackagingCost, accepted.Cost)
+	}
+
+	rec := httptest.NewRecorder()
+	NewHandler(s, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/packages", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `"envelope"`) || !strings.Contains(body, `"bubble"`) {
+		t.Errorf("список упаковки без типов из политики: %s", body)
+	}
+}
+
+func TestStatusFromError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "неверное тело запроса", err: fmt.Errorf("%w: EOF", ErrInvalidBody), want: http.StatusBadRequest},
+		{name: "заказ не найден", err: fmt.Errorf("ошибка: %w", repository.ErrOrderNotFound), want: http.StatusNotFound},
+		{name: "чужой заказ", err: fmt.Errorf("%w: ID 1", service.ErrWrongCustomer), want: http.StatusForbidden},
+		{name: "заказ уже существует", err: service.ErrOrderExists, want: http.StatusConflict},
+		{name: "неверный формат даты", err: service.ErrInvalidDateFormat, want: http.StatusUnprocessableEntity},
+		{name: "недопустимый переход", err: fmt.Errorf("ID 1: %w", model.ErrInvalidTransition), want: http.StatusConflict},
+		{name: "неизвестная ошибка", err: errors.New("сбой диска"), want: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusFromError(tt.err); got != tt.want {
+				t.Errorf("statusFromError(%v) = %d, ожидался %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
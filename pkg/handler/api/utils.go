@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/pricing"
+	"gitlab.ozon.dev/gojhw1/pkg/repository"
+	"gitlab.ozon.dev/gojhw1/pkg/service"
+)
+
+// errorResponse - тело ответа с ошибкой
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// errorStatuses - соответствие ошибок сервиса и репозитория HTTP статусам
+var errorStatuses = []struct {
+	err    error
+	status int
+}{
+	{ErrInvalidBody, http.StatusBadRequest},
+	{ErrInvalidParameter, http.StatusBadRequest},
+	{service.ErrParseFile, http.StatusBadRequest},
+	{service.ErrReadFile, http.StatusBadRequest},
+
+	{repository.ErrOrderNotFound, http.StatusNotFound},
+	{service.ErrUnknownPoint, http.StatusNotFound},
+
+	{service.ErrWrongCustomer, http.StatusForbidden},
+	{service.ErrWrongPickupCode, http.StatusForbidden},
+
+	{service.ErrPickupLocked, http.StatusTooManyRequests},
+
+	{service.ErrOrderExists, http.StatusConflict},
+	{repository.ErrOrderAlreadyExists, http.StatusConflict},
+	{service.ErrWrongState, http.StatusConflict},
+	{service.ErrOrderAlreadyDelivered, http.StatusConflict},
+	{service.ErrAlreadyReturnedToCourier, http.StatusConflict},
+	{service.ErrNoFreeCell, http.StatusConflict},
+	{service.ErrCapacityExceeded, http.StatusConflict},
+	{service.ErrNotDelivered, http.StatusConflict},
+	{service.ErrNotStored, http.StatusConflict},
+	{service.ErrSamePoint, http.StatusConflict},
+	{service.ErrNotExtendable, http.StatusConflict},
+	{service.ErrNoPickupCode, http.StatusConflict},
+	{service.ErrCodeNotIssuable, http.StatusConflict},
+	{service.ErrExtensionLimit, http.StatusConflict},
+	{service.ErrPointNotSelected, http.StatusConflict},
+	{service.ErrDeadlineNotExpired, http.StatusConflict},
+	{service.ErrStorageExpired, http.StatusConflict},
+	{service.ErrReturnExpired, http.StatusConflict},
+	{model.ErrInvalidTransition, http.StatusConflict},
+
+	{service.ErrBatchRejected, http.StatusUnprocessableEntity},
+	{service.ErrStorageDeadlinePassed, http.StatusUnprocessableEntity},
+	{service.ErrInvalidDateFormat, http.StatusUnprocessableEntity},
+	{service.ErrExtensionNotLater, http.StatusUnprocessableEntity},
+	{service.ErrNegativeWeight, http.StatusUnprocessableEntity},
+	{service.ErrNegativeCost, http.StatusUnprocessableEntity},
+	{service.ErrPackageWeightExceeded, http.StatusUnprocessableEntity},
+	{service.ErrUnknownPackageType, http.StatusUnprocessableEntity},
+	{service.ErrUnknownWrapperType, http.StatusUnprocessableEntity},
+	{service.ErrPackageDimensionsExceeded, http.StatusUnprocessableEntity},
+	{service.ErrDimensionsRequired, http.StatusUnprocessableEntity},
+	{service.ErrNoSuitablePackage, http.StatusUnprocessableEntity},
+	{service.ErrAutoWithWrappers, http.StatusUnprocessableEntity},
+	{pricing.ErrUnknownPromoCode, http.StatusUnprocessableEntity},
+	{model.ErrInvalidDimensions, http.StatusUnprocessableEntity},
+	{repository.ErrInvalidOrderID, http.StatusUnprocessableEntity},
+	{repository.ErrInvalidCustomerID, http.StatusUnprocessableEntity},
+}
+
+// statusFromError - возвращает HTTP статус, соответствующий ошибке
+func statusFromError(err error) int {
+	for _, e := range errorStatuses {
+		if errors.Is(err, e.err) {
+			return e.status
+		}
+	}
+
+	return http.StatusInternalServerError
+}
+
+func decodeJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBody, err)
+	}
+
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("ошибка записи ответа: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := statusFromError(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("внутренняя ошибка: %v", err)
+	}
+
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -2,14 +2,15 @@ package commands
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 	"text/tabwriter"
-	"time"
 
+	"gitlab.ozon.dev/gojhw1/pkg/clock"
 	"gitlab.ozon.dev/gojhw1/pkg/model"
 	"gitlab.ozon.dev/gojhw1/pkg/service"
 	"gitlab.ozon.dev/gojhw1/pkg/storage"
@@ -17,31 +18,53 @@ import (
 )
 
 var (
-	ErrInvalidAcceptOrderArgs     = errors.New("использование: accept_order <orderID> <ClientID> <deadline> <weight> <cost> [package_type[+wrapper]]")
+	ErrInvalidAcceptOrderArgs     = errors.New("использование: accept_order <orderID> <ClientID> <deadline> <weight> <cost> [package_type[+wrapper...]] [--dims <Д>x<Ш>x<В>] [--fragile] [--promo <код>]")
 	ErrInvalidReturnCourierArgs   = errors.New("использование: return_to_courier <orderID>")
-	ErrInvalidProcessCustomerArgs = errors.New("использование: process_customer <customerID> <action> <orderID1> [orderID2 ...]")
-	ErrInvalidListOrdersArgs      = errors.New("использование: list_orders <customerID> [pageSize <N>][last <N>] [pvz]")
-	ErrInvalidListReturnsArgs     = errors.New("использование: list_returns pageSize <size>")
-	ErrInvalidAcceptFileArgs      = errors.New("использование: accept_orders_file <filename>")
+	ErrInvalidProcessCustomerArgs = errors.New("использование: process_customer <customerID> handout <orderID>:<code> [...] | process_customer <customerID> return <orderID1> [orderID2 ...]")
+	ErrMissingPickupCode          = errors.New("для выдачи укажите код, который назвал клиент: <orderID>:<code>")
+	ErrInvalidReissueCodeArgs     = errors.New("использование: reissue_code <orderID>")
+	ErrInvalidListOrdersArgs      = errors.New("использование: list_orders <customerID> [pageSize <N>][last <N>] [pvz] [--format <format>]")
+	ErrInvalidListReturnsArgs     = errors.New("использование: list_returns pageSize <size> [--format <format>]")
+	ErrInvalidAcceptFileArgs      = errors.New("использование: accept_orders_file <filename> [--atomic|--best-effort] [--input-format json|ndjson|csv] [--delimiter <char>] [--columns <field>=<column>,...] [--stream [--resume] [--checkpoint <file>] [--checkpoint-every <N>]] [--report <file>] [--format <format>]")
+	ErrStreamAtomic               = errors.New("--stream принимает корректные записи по мере чтения и несовместим с --atomic")
+	ErrInvalidOrderEventsArgs     = errors.New("использование: order_events <orderID> [--format <format>]")
+	ErrInvalidPriceBreakdownArgs  = errors.New("использование: price_breakdown <orderID> [--format <format>]")
 	ErrInvalidPageSize            = errors.New("размер страницы должен быть больше 0")
+	ErrInvalidTimeTravelArgs      = errors.New("использование: time_travel <timestamp|duration|reset>")
+	ErrInvalidExtendStorageArgs   = errors.New("использование: extend_storage <orderID> <duration|date>")
+	ErrInvalidTransferArgs        = errors.New("использование: transfer_order <orderID> <targetPoint>")
+	ErrInvalidUsePointArgs        = errors.New("использование: use_point [<pointID>]")
+	ErrInvalidSuggestPackageArgs  = errors.New("использование: suggest_package <weight> [<Д>x<Ш>x<В>] [--format <format>]")
+	ErrConfirmationRequired       = errors.New("в неинтерактивном режиме очистка базы требует подтверждения: clear_db --yes")
+
+	// ErrExit - сигнализирует о том, что пользователь запросил завершение работы
+	ErrExit = errors.New("выход")
 )
 
 const timeLayout = "2006-01-02T15:04:05"
 const defaultPageSize = 5
 
+// checkpointSuffix - суффикс файла контрольной точки потокового приема рядом с файлом заказов
+const checkpointSuffix = ".checkpoint"
+
 type CommandFunc func([]string) error
 
 type Handler struct {
-	service  *service.OrderService
-	storage  storage.OrderStorage
-	commands map[string]CommandFunc
+	service     *service.OrderService
+	storage     storage.OrderStorage
+	commands    map[string]CommandFunc
+	interactive bool
+	format      outputFormat
 }
 
-// NewHandler - Создает новый обработчик команд
+// NewHandler - Создает новый обработчик команд.
+// storage может быть nil, если репозиторий сервиса сам сохраняет данные (например, SQL)
 func NewHandler(service *service.OrderService, storage storage.OrderStorage) *Handler {
 	Handler := &Handler{
-		service: service,
-		storage: storage,
+		service:     service,
+		storage:     storage,
+		interactive: true,
+		format:      formatTable,
 	}
 
 	Handler.commands = map[string]CommandFunc{
@@ -50,30 +73,64 @@ func NewHandler(service *service.OrderService, storage storage.OrderStorage) *Ha
 			return nil
 		},
 		"exit": func(_ []string) error {
-			fmt.Println("Выход...")
-			os.Exit(0)
-			return nil
+			return ErrExit
 		},
 		"clear": func(_ []string) error {
 			Handler.clearTerminal()
 			return nil
 		},
-		"clear_db": func(_ []string) error {
-			return Handler.clearDatabase()
+		"show_policy": func(_ []string) error {
+			return Handler.showPolicy()
+		},
+		"capacity": func(_ []string) error {
+			return Handler.capacity()
 		},
-		"order_history": func(_ []string) error {
-			return Handler.orderHistory()
+		"points": func(_ []string) error {
+			return Handler.listPoints()
 		},
+		"list_packages": func(_ []string) error {
+			return Handler.listPackages()
+		},
+		"suggest_package":    Handler.suggestPackage,
+		"use_point":          Handler.usePoint,
+		"clear_db":           Handler.clearDatabase,
+		"order_history":      Handler.orderHistory,
+		"order_events":       Handler.orderEvents,
+		"price_breakdown":    Handler.priceBreakdown,
 		"accept_order":       Handler.acceptOrder,
 		"return_to_courier":  Handler.returnToCourier,
+		"transfer_order":     Handler.transferOrder,
+		"extend_storage":     Handler.extendStorage,
+		"reissue_code":       Handler.reissueCode,
 		"process_customer":   Handler.processCustomer,
 		"list_orders":        Handler.listOrders,
 		"list_returns":       Handler.listReturns,
 		"accept_orders_file": Handler.acceptOrdersFromFile,
+		// Скрытая команда для обучения персонала и воспроизведения граничных случаев по срокам
+		"time_travel": Handler.timeTravel,
 	}
 	return Handler
 }
 
+// SetInteractive - Включает или выключает интерактивный режим.
+// В неинтерактивном режиме команды не ждут ввода с клавиатуры: списки выводятся целиком,
+// а подтверждения передаются аргументами
+func (h *Handler) SetInteractive(interactive bool) {
+	h.interactive = interactive
+}
+
+// SetFormat - Задает формат вывода списков по умолчанию: table, json, csv или yaml.
+// Отдельная команда может переопределить его аргументом --format
+func (h *Handler) SetFormat(format string) error {
+	f, err := parseFormat(format)
+	if err != nil {
+		return err
+	}
+	h.format = f
+
+	return nil
+}
+
 // Execute - Выполняет команду с переданными аргументами
 func (h *Handler) Execute(command string, args []string) error {
 	cmdFunc, exists := h.commands[command]
@@ -96,50 +153,152 @@ func (h *Handler) printHelp() {
 	exit                          - завершить программу
 	clear                         - очистить консоль
 
-	accept_order <orderID> <clientID> <deadline> <weight> <cost> [package_type[+wrapper]]
+	accept_order <orderID> <clientID> <deadline> <weight> <cost> [package_type[+wrapper...]] [--dims <Д>x<Ш>x<В>]
+	             [--fragile] [--promo <код>]
 		Принять заказ от курьера.
 		deadline в формате "YYYY-MM-DDTHH:MM:SS",
 		либо как относительная длительность (например, "30s" или "48h")
 		weight - вес заказа в кг
 		cost - стоимость заказа в рублях
 		package_type - тип упаковки (box - коробка, bag - пакет, film - пленка)
-		wrapper - дополнительная обертка (film - пленка)
+		wrapper - дополнительные обертки (film - пленка), можно указать несколько: box+film+bubble.
+		          Каждая обертка добавляет свою стоимость и может изменить максимальный вес упаковки
+		--dims - габариты заказа в см. Проверяются по максимальным габаритам упаковки;
+		         для упаковки с объемным весом обязательны, и ее стоимость считается по большему
+		         из фактического и объемного веса
+		--fragile - хрупкий заказ, --promo - промокод на скидку.
+		Стоимость заказа рассчитывается по правилам pricing из политики, разбивка - price_breakdown
 		Примеры:
 			accept_order 1 1 "48h" 5.0 100.0 box
 			accept_order 1 1 "48h" 5.0 100.0 box+film
 			accept_order 1 1 "2030-02-20T15:04:05" 5.0 100.0 bag+film
+			accept_order 1 1 "48h" 5.0 100.0 box --dims 40x30x20
+			accept_order 1 1 "48h" 5.0 100.0 box --fragile --promo SPRING10
 
 	return_to_courier <orderID>
 		Вернуть заказ курьеру.
-
-	process_customer <customerID> <action> <orderID1> [orderID2 ...]
+		Заказ остается в истории в конечном состоянии returned_to_courier.
+
+	extend_storage <orderID> <duration|date>
+		Продлить срок хранения принятого заказа по просьбе клиента.
+		duration добавляется к текущему сроку (например, "48h"),
+		date - новый срок в формате "YYYY-MM-DDTHH:MM:SS".
+		Число и суммарная длительность продлений ограничены политикой (extension),
+		плата за продление добавляется к стоимости заказа.
+
+	transfer_order <orderID> <targetPoint>
+		Переместить принятый заказ в другой пункт выдачи (например, ближе к дому клиента).
+		Заказ проходит состояние in_transit и принимается в пункте назначения с новой ячейкой.
+		Срок хранения сохраняется или отсчитывается заново по правилам transfer из политики.
+
+	process_customer <customerID> handout <orderID>:<code> [<orderID>:<code> ...]
+	process_customer <customerID> return <orderID1> [orderID2 ...]
 		Выдать заказы или принять возврат клиента.
-		action: "handout" или "return".
+		Для выдачи нужен одноразовый код, который клиент получил в уведомлении.
+		После нескольких неверных попыток выдача заказа временно блокируется.
+		Заказы обрабатываются атомарно: при ошибке по одному из заказов не изменяется ни один.
+
+	reissue_code <orderID>
+		Выпустить новый код выдачи взамен утерянного и снять блокировку выдачи.
+		Код передается клиенту через выгрузку для системы уведомлений.
 
 	list_orders <customerID> [pageSize <N>] [last <N>] [pvz]
 		Получить список заказов с пагинацией скроллом.
 		По умолчанию - размер страницы = 5
+		В неинтерактивном режиме выводится весь список.
 
 	list_returns pageSize <size>
 		Получить список возвратов с интерактивной пагинацией (нажатие Enter – следующая страница).
+		В неинтерактивном режиме выводятся все страницы сразу.
 
 	order_history
 		Получить историю заказов.
 
-	accept_orders_file <filename>
-		Принять заказы от курьера из указанного JSON файла.
-
-	clear_db
-		Очистить базу данных.
+	order_events <orderID>
+		Показать историю изменений заказа: кто, когда и какой командой изменил его состояние.
+		Доступна и для заказов, уже возвращенных курьеру.
+
+	price_breakdown <orderID>
+		Показать, из чего сложилась стоимость заказа: строки правил расчета в порядке применения,
+		плата за продление и хранение. Для хранящегося в ПВЗ заказа хранение считается на текущий момент.
+
+	Команды list_orders, list_returns, order_history, order_events и price_breakdown принимают аргумент --format <table|json|csv|yaml>.
+	В форматах json, csv и yaml выводятся все поля заказов целиком, без пагинации.
+
+	accept_orders_file <filename> [--atomic|--best-effort] [--report <file>]
+	                   [--input-format json|ndjson|csv] [--delimiter <char>] [--columns <field>=<column>,...]
+		Принять заказы от курьера из файла: JSON массива, NDJSON (по объекту на строку) или CSV с заголовком.
+		Формат определяется по расширению (.json, .ndjson/.jsonl, .csv/.tsv) или содержимому,
+		либо задается через --input-format.
+		--delimiter - разделитель CSV (по умолчанию ",", "tab" - табуляция)
+		--columns   - заголовки столбцов CSV, если они отличаются от названий полей, например:
+		              --columns id=Номер,customer_id=Клиент,deadline_at=Срок
+		Сначала проверяются все записи файла, затем:
+		--atomic      - (по умолчанию) заказы принимаются, только если корректны все записи
+		--best-effort - принимаются корректные записи, остальные отклоняются
+		Выводится результат по каждой записи; --report <file> - сохранить отчет в JSON файл.
+		Поддерживает --format <table|json|csv|yaml>.
+		--stream [--resume] [--checkpoint <file>] [--checkpoint-every <N>]
+		              - потоковый прием больших файлов: корректные записи принимаются порциями по N
+		              (по умолчанию 1000) без загрузки файла в память, после каждой порции данные
+		              сохраняются и записывается контрольная точка (по умолчанию <filename>.checkpoint).
+		              --resume продолжает прерванный прием с контрольной точки.
+		              Отчет (--report) записывается по одному JSON объекту на строку.
+
+	show_policy
+		Показать действующие бизнес-правила: срок возврата, тарифы и ограничения упаковки, стеллажи с ячейками.
+
+	list_packages
+		Показать доступные типы упаковки и обертки: стоимость, максимальный вес и габариты.
+		Новые типы добавляются в разделы packages и wrappers файла политики.
+
+	suggest_package <weight> [<Д>x<Ш>x<В>] [--format <format>]
+		Подобрать самую дешевую упаковку для заказа весом weight кг с габаритами в см.
+		Проверяется каждый тип упаковки из реестра, а если заказ для него тяжел - сочетания оберток,
+		увеличивающих максимальный вес. Для неподходящих вариантов выводится причина.
+		Тот же подбор выполняет accept_order с типом упаковки auto:
+			accept_order 1 1 "48h" 5.0 100.0 auto --dims 40x30x20
+
+	capacity
+		Показать текущую загрузку ПВЗ: число и вес хранящихся заказов, заказы по типам упаковки,
+		занятые ячейки - и лимиты вместимости из политики.
+
+	points
+		Показать пункты выдачи из политики и их загрузку. Текущий пункт отмечен *.
+
+	use_point [<pointID>]
+		Переключиться на другой пункт выдачи; без аргумента - показать текущий.
+		Прием, выдача, возвраты, списки заказов и загрузка относятся только к выбранному пункту.
+
+	clear_db [--yes]
+		Очистить базу данных, а если выбран пункт выдачи - удалить только его заказы.
+		--yes - без запроса подтверждения.
 `)
 }
 
 func (h *Handler) saveData() error {
-	if h.service == nil || h.storage == nil {
-		return errors.New("service or storage is nil")
+	if h.service == nil {
+		return errors.New("service is nil")
 	}
-	data := h.service.Repo().GetAll()
-	if err := h.storage.Save(data); err != nil {
+	if h.storage == nil {
+		return nil
+	}
+	data, err := h.service.Repo().GetAll()
+	if err != nil {
+		return fmt.Errorf("ошибка чтения данных: %v", err)
+	}
+	if err = h.storage.Save(data); err != nil {
+		return fmt.Errorf("ошибка сохранения данных: %v", err)
+	}
+	return nil
+}
+
+// saveOrders - сохраняет в хранилище только переданные заказы
+func (h *Handler) saveOrders(orders []model.Order) error {
+	if h.storage == nil || len(orders) == 0 {
+		return nil
+	}
+	if err := h.storage.Put(orders); err != nil {
 		return fmt.Errorf("ошибка сохранения данных: %v", err)
 	}
 	return nil
@@ -147,11 +306,15 @@ func (h *Handler) saveData() error {
 
 // acceptOrder - Принимает заказ от курьера
 func (h *Handler) acceptOrder(args []string) error {
-	if err := validateAcceptOrderArgs(args); err != nil {
+	opts, args, err := extractAcceptOptions(args)
+	if err != nil {
+		return err
+	}
+	if err = validateAcceptOrderArgs(args); err != nil {
 		return err
 	}
 
-	params, err := parseAcceptOrderParams(args)
+	params, err := parseAcceptOrderParams(args, h.service.ParseDeadline)
 	if err != nil {
 		return err
 	}
@@ -163,7 +326,8 @@ func (h *Handler) acceptOrder(args []string) error {
 		params.weight,
 		params.cost,
 		params.packageType,
-		params.wrapper,
+		params.wrappers,
+		opts,
 	); err != nil {
 		return fmt.Errorf("ошибка при принятии заказа: %v", err)
 	}
@@ -172,7 +336,15 @@ func (h *Handler) acceptOrder(args []string) error {
 		return err
 	}
 
-	fmt.Printf("Заказ принят. Итоговая стоимость: %.2f\n", h.service.Repo().GetAll()[params.orderID].Cost)
+	order, err := h.service.Repo().FindByID(params.orderID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Заказ принят. Итоговая стоимость: %.2f\n", order.Cost)
+	if order.Cell != "" {
+		fmt.Println("Ячейка хранения:", order.Cell)
+	}
 	return nil
 }
 
@@ -200,7 +372,8 @@ func (h *Handler) returnToCourier(args []string) error {
 	return nil
 }
 
-// processCustomer - Обрабатывает выдачу или возврат заказов клиенту
+// processCustomer - Обрабатывает выдачу или возврат заказов клиенту.
+// Заказы обрабатываются атомарно: при ошибке хотя бы по одному заказу не изменяется ни один
 func (h *Handler) processCustomer(args []string) error {
 	if len(args) < 3 {
 		return ErrInvalidProcessCustomerArgs
@@ -211,41 +384,55 @@ func (h *Handler) processCustomer(args []string) error {
 		return fmt.Errorf("неверный формат customerID: %w", err)
 	}
 
-	action := args[1]
-	orderIDs := args[2:]
-	now := time.Now()
+	// неверные попытки ввода кода выдачи сохраняются, даже если выдача не состоялась
+	actionErr := h.processCustomerAction(args[1], args[2:], customerID)
+	if err = h.saveData(); err != nil {
+		return err
+	}
 
-	for _, idStr := range orderIDs {
-		id, err := strconv.ParseInt(idStr, 10, 64)
-		if err != nil {
-			return fmt.Errorf("неверный формат orderID: %w", err)
-		}
+	return actionErr
+}
 
-		if err = h.processCustomerAction(action, id, customerID, now); err != nil {
-			return err
+// orderCells - возвращает ячейки хранения выдаваемых заказов; не найденные заказы пропускаются
+func (h *Handler) orderCells(handouts []service.Handout) map[int64]string {
+	cells := make(map[int64]string, len(handouts))
+	for _, handout := range handouts {
+		if order, err := h.service.Repo().FindByID(handout.OrderID); err == nil {
+			cells[handout.OrderID] = order.Cell
 		}
 	}
 
-	err = h.saveData()
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return cells
 }
 
-func (h *Handler) processCustomerAction(action string, id, customerID int64, now time.Time) error {
+func (h *Handler) processCustomerAction(action string, args []string, customerID int64) error {
 	switch action {
 	case "handout":
-		if err := h.service.DeliverOrder(id, customerID, now); err != nil {
-			return fmt.Errorf("ошибка при выдаче заказа: %v", err)
+		handouts, err := parseHandouts(args)
+		if err != nil {
+			return err
+		}
+		// ячейки освобождаются при выдаче, поэтому запоминаются до нее
+		cells := h.orderCells(handouts)
+		if err = h.service.DeliverOrders(handouts, customerID); err != nil {
+			return fmt.Errorf("ошибка при выдаче заказа, ни один заказ не выдан: %v", err)
+		}
+		for _, handout := range handouts {
+			if err = h.printHandout(handout.OrderID, customerID, cells[handout.OrderID]); err != nil {
+				return err
+			}
 		}
-		fmt.Printf("Заказ ID %d выдан клиенту %d\n", id, customerID)
 	case "return":
-		if err := h.service.ProcessReturnOrder(id, customerID, now); err != nil {
-			return fmt.Errorf("ошибка при обработке возврата: %v", err)
+		ids, err := parseOrderIDs(args)
+		if err != nil {
+			return err
+		}
+		if err = h.service.ProcessReturnOrders(ids, customerID); err != nil {
+			return fmt.Errorf("ошибка при обработке возврата, ни один возврат не принят: %v", err)
+		}
+		for _, id := range ids {
+			h.printReturn(id)
 		}
-		fmt.Println("Возврат принят для заказа ", id)
 	default:
 		return fmt.Errorf("неизвестное действие: %s", action)
 	}
@@ -253,16 +440,54 @@ func (h *Handler) processCustomerAction(action string, id, customerID int64, now
 	return nil
 }
 
+// printHandout - Выводит сообщение о выдаче заказа и разбивку суммы к оплате
+func (h *Handler) printHandout(id, customerID int64, cell string) error {
+	if cell != "" {
+		fmt.Printf("Заказ ID %d выдан клиенту %d из ячейки %s\n", id, customerID, cell)
+	} else {
+		fmt.Printf("Заказ ID %d выдан клиенту %d\n", id, customerID)
+	}
+
+	order, err := h.service.Repo().FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	return writeCharges(os.Stdout, h.service.OrderCharges(order))
+}
+
+// printReturn - Выводит сообщение о приеме возврата и ячейку, в которую он положен
+func (h *Handler) printReturn(id int64) {
+	order, err := h.service.Repo().FindByID(id)
+	if err == nil && order.Cell != "" {
+		fmt.Printf("Возврат принят для заказа %d, ячейка %s\n", id, order.Cell)
+		return
+	}
+
+	fmt.Println("Возврат принят для заказа ", id)
+}
+
 // orderHistory - Выводит историю заказов
-func (h *Handler) orderHistory() error {
-	orders := h.service.OrderHistory()
+func (h *Handler) orderHistory(args []string) error {
+	format, _, err := extractFormat(args, h.format)
+	if err != nil {
+		return err
+	}
+
+	orders, err := h.service.OrderHistory()
+	if err != nil {
+		return fmt.Errorf("ошибка получения истории заказов: %v", err)
+	}
+	if format != formatTable {
+		return writeRecords(os.Stdout, format, orders)
+	}
 	if len(orders) == 0 {
 		fmt.Println("База пуста")
 		return nil
 	}
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 
-	if _, err := fmt.Fprintln(w, "ID\tКлиент\tСрок хранения\tСостояние\tВес\tСтоимость\tУпаковка\tОбновлен\tДоставлен\tВозврат"); err != nil {
+	if _, err := fmt.Fprintln(w, "ID\tКлиент\tСрок хранения\tСостояние\tЯчейка\tВес\tСтоимость\tУпаковка\tОбновлен\tДоставлен\tВозврат"); err != nil {
 		return fmt.Errorf("ошибка при записи заголовка: %v", err)
 	}
 
@@ -276,11 +501,12 @@ func (h *Handler) orderHistory() error {
 			ret = order.ReturnedAt.Format(timeLayout)
 		}
 
-		if _, err := fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%.2f\t%.2f\t%s\t%s\t%s\t%s\n",
+		if _, err := fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%.2f\t%.2f\t%s\t%s\t%s\t%s\n",
 			order.ID,
 			order.CustomerID,
 			order.DeadlineAt.Format(timeLayout),
 			order.State,
+			formatCell(order.Cell),
 			order.Weight,
 			order.Cost,
 			formatPackageInfo(order),
@@ -298,8 +524,68 @@ func (h *Handler) orderHistory() error {
 	return nil
 }
 
+// orderEvents - Выводит историю изменений заказа
+func (h *Handler) orderEvents(args []string) error {
+	format, args, err := extractFormat(args, h.format)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return ErrInvalidOrderEventsArgs
+	}
+
+	orderID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("неверный формат orderID: %v", err)
+	}
+
+	events, err := h.service.OrderEvents(orderID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения истории заказа: %v", err)
+	}
+	if format != formatTable {
+		return writeRecords(os.Stdout, format, events)
+	}
+	if len(events) == 0 {
+		fmt.Println("Нет событий для заказа", orderID)
+		return nil
+	}
+
+	return writeEventsTable(os.Stdout, events)
+}
+
+// priceBreakdown - Выводит, из чего сложилась стоимость заказа
+func (h *Handler) priceBreakdown(args []string) error {
+	format, args, err := extractFormat(args, h.format)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return ErrInvalidPriceBreakdownArgs
+	}
+
+	orderID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("неверный формат orderID: %v", err)
+	}
+
+	breakdown, err := h.service.PriceBreakdown(orderID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения стоимости заказа: %v", err)
+	}
+	if format != formatTable {
+		return writeRecords(os.Stdout, format, breakdown.Lines)
+	}
+
+	return writePriceBreakdown(os.Stdout, breakdown)
+}
+
 // listReturns - Выводит список возвратов с пагинацией
 func (h *Handler) listReturns(args []string) error {
+	format, args, err := extractFormat(args, h.format)
+	if err != nil {
+		return err
+	}
 	if len(args) < 2 || args[0] != "pageSize" {
 		return ErrInvalidListReturnsArgs
 	}
@@ -312,28 +598,49 @@ func (h *Handler) listReturns(args []string) error {
 		return ErrInvalidPageSize
 	}
 
-	returns := h.service.ListReturns()
+	returns, err := h.service.ListReturns()
+	if err != nil {
+		return fmt.Errorf("ошибка получения списка возвратов: %v", err)
+	}
+	if format != formatTable {
+		return writeRecords(os.Stdout, format, returns)
+	}
 	if len(returns) == 0 {
 		fmt.Println("Нет данных для возвратов")
 		return nil
 	}
 
-	return listReturnsPrintFull(returns, pageSize)
+	return listReturnsPrintFull(returns, pageSize, h.interactive)
 }
 
 // listOrders - Выводит список заказов с пагинацией
 func (h *Handler) listOrders(args []string) error {
+	format, args, err := extractFormat(args, h.format)
+	if err != nil {
+		return err
+	}
+
 	params, err := parseListOrdersParams(args)
 	if err != nil {
 		return err
 	}
 
-	ordersList := h.service.ListOrders(params.customerID, params.lastN, params.filterPVZ)
+	ordersList, err := h.service.ListOrders(params.customerID, params.lastN, params.filterPVZ)
+	if err != nil {
+		return fmt.Errorf("ошибка получения списка заказов: %v", err)
+	}
+	if format != formatTable {
+		return writeRecords(os.Stdout, format, h.service.Listing(ordersList))
+	}
 	if len(ordersList) == 0 {
 		fmt.Println("Нет заказов")
 		return nil
 	}
 
+	if !h.interactive {
+		return writeLOTable(os.Stdout, ordersList, h.service.StorageFee)
+	}
+
 	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
 	if err != nil {
 		return fmt.Errorf("ошибка при настройке терминала: %v", err)
@@ -356,44 +663,369 @@ func (h *Handler) listOrders(args []string) error {
 	return handleLOKeyPress(displayFunc, &currentPos, totalOrders, params.pageSize)
 }
 
-// acceptOrdersFromFile - Принимает заказы из JSON файла
+// acceptOrdersFromFile - Принимает заказы из JSON файла и выводит результат по каждой записи
 func (h *Handler) acceptOrdersFromFile(args []string) error {
-	if len(args) < 1 {
-		return ErrInvalidAcceptFileArgs
+	format, args, err := extractFormat(args, h.format)
+	if err != nil {
+		return err
+	}
+	params, err := parseAcceptFileParams(args)
+	if err != nil {
+		return err
+	}
+
+	if params.stream {
+		return h.streamOrdersFromFile(params, format)
+	}
+
+	report, batchErr := h.service.AcceptOrdersFromFile(params.filename, params.mode, params.importOpts)
+	if report == nil {
+		return fmt.Errorf("ошибка при загрузке заказов из файла: %v", batchErr)
+	}
+
+	if report.Accepted > 0 {
+		if err = h.saveData(); err != nil {
+			return err
+		}
+	}
+	if params.reportPath != "" {
+		if err = writeBatchReportFile(params.reportPath, report); err != nil {
+			return err
+		}
+	}
+	if err = writeBatchReport(os.Stdout, format, report); err != nil {
+		return err
+	}
+
+	return batchErr
+}
+
+// streamOrdersFromFile - Принимает заказы из большого файла порциями с контрольными точками
+func (h *Handler) streamOrdersFromFile(params acceptFileParams, format outputFormat) error {
+	opts := service.StreamOptions{
+		CheckpointPath:  params.checkpointPath,
+		CheckpointEvery: params.checkpointEvery,
+		Resume:          params.resume,
+		Commit:          h.saveOrders,
+		Progress: func(progress service.StreamProgress) {
+			printStreamProgress(progress, h.interactive)
+		},
+	}
+
+	if params.reportPath != "" {
+		reportFile, err := openStreamReport(params.reportPath, params.resume)
+		if err != nil {
+			return err
+		}
+		defer reportFile.Close()
+
+		encoder := json.NewEncoder(reportFile)
+		opts.Result = func(result service.AcceptResult) {
+			if err := encoder.Encode(result); err != nil {
+				fmt.Fprintf(os.Stderr, "ошибка записи отчета: %v\n", err)
+			}
+		}
+	}
+
+	report, err := h.service.StreamOrdersFromFile(params.filename, params.importOpts, opts)
+	if h.interactive {
+		fmt.Fprintln(os.Stderr)
 	}
-	filename := args[0]
-	if err := h.service.AcceptOrdersFromFile(filename); err != nil {
+	if err != nil {
+		if _, statErr := os.Stat(params.checkpointPath); statErr == nil {
+			return fmt.Errorf("прием прерван, продолжить с контрольной точки можно с --resume: %v", err)
+		}
 		return fmt.Errorf("ошибка при загрузке заказов из файла: %v", err)
 	}
 
-	err := h.saveData()
+	if format != formatTable {
+		return writeRecords(os.Stdout, format, []service.BatchReport{*report})
+	}
+
+	return writeBatchSummary(os.Stdout, report)
+}
+
+// showPolicy - Выводит действующую политику пункта выдачи
+func (h *Handler) showPolicy() error {
+	p := h.service.Policy()
+
+	source := p.Source
+	if source == "" {
+		source = "политика по умолчанию"
+	}
+	fmt.Println("Источник:", source)
+	fmt.Println("Срок возврата:", p.ReturnWindow)
+	fmt.Printf("Продление хранения: число продлений - %s, суммарно - %s, плата - %.2f\n",
+		formatLimit(float64(p.Extension.MaxCount), "%.0f"), formatDurationLimit(p.Extension.MaxTotal.Duration), p.Extension.Fee)
+	fmt.Println("Срок хранения после перемещения:", p.Transfer.Deadline)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w); err != nil {
+		return fmt.Errorf("ошибка при записи данных: %v", err)
+	}
+	registry := h.service.Packaging()
+	if err := writePackaging(w, registry.Packages(), registry.Wrappers()); err != nil {
+		return err
+	}
+
+	if err := writePolicyCells(w, p.Cells); err != nil {
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("ошибка при выводе таблицы: %v", err)
+	}
+
+	return nil
+}
+
+// listPackages - Выводит типы упаковки и обертки из реестра упаковки
+func (h *Handler) listPackages() error {
+	registry := h.service.Packaging()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if err := writePackaging(w, registry.Packages(), registry.Wrappers()); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("ошибка при выводе таблицы: %v", err)
+	}
+
+	return nil
+}
+
+// suggestPackage - Подбирает самую дешевую подходящую упаковку для заказа
+func (h *Handler) suggestPackage(args []string) error {
+	format, args, err := extractFormat(args, h.format)
 	if err != nil {
 		return err
 	}
+	if len(args) < 1 || len(args) > 2 {
+		return ErrInvalidSuggestPackageArgs
+	}
+
+	weight, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return fmt.Errorf("неверный формат веса: %v", err)
+	}
+
+	var dimensions *model.Dimensions
+	if len(args) == 2 {
+		d, err := model.ParseDimensions(args[1])
+		if err != nil {
+			return err
+		}
+		dimensions = &d
+	}
+
+	suggestion, err := h.service.SuggestPackage(weight, dimensions)
+	if err != nil {
+		return fmt.Errorf("ошибка подбора упаковки: %v", err)
+	}
+	if format != formatTable {
+		return writeRecords(os.Stdout, format, suggestion.Options)
+	}
+
+	return writeSuggestion(os.Stdout, suggestion)
+}
+
+// capacity - Выводит текущую загрузку ПВЗ и лимиты вместимости
+func (h *Handler) capacity() error {
+	usage, err := h.service.Utilization()
+	if err != nil {
+		return fmt.Errorf("ошибка получения загрузки ПВЗ: %v", err)
+	}
+
+	return writeCapacity(os.Stdout, usage, h.service.Policy().Capacity)
+}
+
+// reissueCode - Выпускает новый код выдачи заказа
+func (h *Handler) reissueCode(args []string) error {
+	if len(args) < 1 {
+		return ErrInvalidReissueCodeArgs
+	}
+
+	orderID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("неверный формат orderID: %v", err)
+	}
+
+	if err = h.service.ReissuePickupCode(orderID); err != nil {
+		return fmt.Errorf("ошибка при выпуске кода выдачи: %v", err)
+	}
+	if err = h.saveData(); err != nil {
+		return err
+	}
+	fmt.Println("Новый код выдачи заказа", orderID, "передан в систему уведомлений")
 
 	return nil
 }
 
-// clearDatabase - Очищает базу данных
-func (h *Handler) clearDatabase() error {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("Вы уверены, что хотите очистить базу? (Y/N): ")
-	confirm, err := reader.ReadString('\n')
+// extendStorage - Продлевает срок хранения заказа
+func (h *Handler) extendStorage(args []string) error {
+	if len(args) < 2 {
+		return ErrInvalidExtendStorageArgs
+	}
+
+	orderID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("неверный формат orderID: %v", err)
+	}
+
+	order, err := h.service.ExtendStorage(orderID, args[1])
 	if err != nil {
-		return fmt.Errorf("ошибка при чтении подтверждения: %v", err)
+		return fmt.Errorf("ошибка при продлении срока хранения: %v", err)
+	}
+	if err = h.saveData(); err != nil {
+		return err
 	}
+	fmt.Printf("Срок хранения заказа %d продлен до %s. Итоговая стоимость: %.2f\n",
+		orderID, order.DeadlineAt.Format(timeLayout), order.Cost)
 
-	confirm = strings.TrimSpace(confirm)
-	if strings.ToUpper(confirm) != "Y" {
+	return nil
+}
+
+// transferOrder - Перемещает заказ в другой пункт выдачи
+func (h *Handler) transferOrder(args []string) error {
+	if len(args) < 2 {
+		return ErrInvalidTransferArgs
+	}
+
+	orderID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("неверный формат orderID: %v", err)
+	}
+	target := args[1]
+
+	if err = h.service.TransferOrder(orderID, target); err != nil {
+		return fmt.Errorf("ошибка при перемещении заказа: %v", err)
+	}
+	if err = h.saveData(); err != nil {
+		return err
+	}
+
+	destination, err := h.service.ForPoint(target)
+	if err != nil {
+		return err
+	}
+	order, err := destination.Repo().FindByID(orderID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Заказ %d перемещен в пункт выдачи %s. Срок хранения: %s\n", orderID, target, order.DeadlineAt.Format(timeLayout))
+	if order.Cell != "" {
+		fmt.Println("Ячейка хранения:", order.Cell)
+	}
+
+	return nil
+}
+
+// listPoints - Выводит пункты выдачи и их загрузку
+func (h *Handler) listPoints() error {
+	points, err := h.service.Points()
+	if err != nil {
+		return fmt.Errorf("ошибка получения пунктов выдачи: %v", err)
+	}
+	if len(points) == 0 {
+		fmt.Println("Пункты выдачи в политике не заданы, все заказы относятся к одному ПВЗ.")
+		return nil
+	}
+
+	return writePointsTable(os.Stdout, points, h.service.Point())
+}
+
+// usePoint - Переключает обработчик на другой пункт выдачи
+func (h *Handler) usePoint(args []string) error {
+	if len(args) > 1 {
+		return ErrInvalidUsePointArgs
+	}
+	if len(args) == 0 {
+		if h.service.Point() == "" {
+			fmt.Println("Пункт выдачи не выбран.")
+			return nil
+		}
+		fmt.Println("Текущий пункт выдачи:", h.service.Point())
+		return nil
+	}
+
+	scoped, err := h.service.ForPoint(args[0])
+	if err != nil {
+		return err
+	}
+	h.service = scoped
+	fmt.Println("Выбран пункт выдачи:", scoped.Point())
+
+	return nil
+}
+
+// timeTravel - Переводит часы сервиса на указанный момент или сдвигает их на длительность.
+// reset возвращает системное время
+func (h *Handler) timeTravel(args []string) error {
+	if len(args) < 1 {
+		return ErrInvalidTimeTravelArgs
+	}
+
+	if args[0] == "reset" {
+		h.service.SetClock(clock.Real{})
+		fmt.Println("Часы переведены на системное время:", h.service.Now().Format(timeLayout))
+		return nil
+	}
+
+	target, err := h.service.ParseDeadline(args[0])
+	if err != nil {
+		return err
+	}
+
+	if fake, ok := h.service.Clock().(*clock.Fake); ok {
+		fake.Set(target)
+	} else {
+		h.service.SetClock(clock.NewFake(target))
+	}
+	fmt.Println("Часы переведены на:", target.Format(timeLayout))
+
+	return nil
+}
+
+// clearDatabase - Очищает базу данных. Аргумент --yes отменяет запрос подтверждения
+func (h *Handler) clearDatabase(args []string) error {
+	confirmed, err := h.confirmClearDatabase(args)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
 		fmt.Println("Операция отменена.")
 		return nil
 	}
 
-	h.service.Repo().SetAll(make(map[int64]model.Order))
-	if err = h.storage.Save(h.service.Repo().GetAll()); err != nil {
+	if err = h.service.ClearOrders(); err != nil {
+		return fmt.Errorf("ошибка при очистке базы данных: %v", err)
+	}
+	if err = h.saveData(); err != nil {
 		return fmt.Errorf("ошибка при очистке базы данных: %v", err)
 	}
+	if point := h.service.Point(); point != "" {
+		fmt.Println("Заказы пункта выдачи", point, "удалены.")
+		return nil
+	}
 	fmt.Println("База успешно очищена.")
 
 	return nil
 }
+
+func (h *Handler) confirmClearDatabase(args []string) (bool, error) {
+	if len(args) > 0 && (args[0] == "--yes" || args[0] == "-y") {
+		return true, nil
+	}
+	if !h.interactive {
+		return false, ErrConfirmationRequired
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Print("Вы уверены, что хотите очистить базу? (Y/N): ")
+	confirm, err := reader.ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("ошибка при чтении подтверждения: %v", err)
+	}
+
+	return strings.ToUpper(strings.TrimSpace(confirm)) == "Y", nil
+}
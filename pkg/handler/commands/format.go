@@ -0,0 +1,226 @@
+package commands
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownFormat = errors.New("неизвестный формат вывода, допустимые: table, json, csv, yaml")
+)
+
+type outputFormat string
+
+const (
+	formatTable outputFormat = "table"
+	formatJSON  outputFormat = "json"
+	formatCSV   outputFormat = "csv"
+	formatYAML  outputFormat = "yaml"
+)
+
+const formatFlag = "--format"
+
+// parseFormat - проверяет название формата вывода
+func parseFormat(s string) (outputFormat, error) {
+	switch f := outputFormat(strings.ToLower(s)); f {
+	case formatTable, formatJSON, formatCSV, formatYAML:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownFormat, s)
+	}
+}
+
+// extractFormat - извлекает из аргументов команды "--format <name>" или "--format=<name>".
+// Если формат не указан, возвращается def
+func extractFormat(args []string, def outputFormat) (outputFormat, []string, error) {
+	format := def
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		value, ok := strings.CutPrefix(args[i], formatFlag+"=")
+		if !ok && args[i] == formatFlag {
+			if i+1 >= len(args) {
+				return "", nil, fmt.Errorf("%w: не указано значение %s", ErrUnknownFormat, formatFlag)
+			}
+			i++
+			value, ok = args[i], true
+		}
+		if !ok {
+			rest = append(rest, args[i])
+			continue
+		}
+
+		f, err := parseFormat(value)
+		if err != nil {
+			return "", nil, err
+		}
+		format = f
+	}
+
+	return format, rest, nil
+}
+
+// field - именованное значение записи в порядке вывода
+type field struct {
+	name  string
+	value any
+}
+
+// recordFields - раскладывает структуру на поля с именами из JSON тегов.
+// Поля с тегом "-" и неэкспортируемые поля пропускаются
+func recordFields(record any) []field {
+	return appendFields(nil, reflect.Indirect(reflect.ValueOf(record)))
+}
+
+// appendFields - добавляет поля структуры v. Поля встроенной структуры без JSON имени выводятся
+// на уровне внешней структуры, как и в encoding/json
+func appendFields(fields []field, v reflect.Value) []field {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if !sf.IsExported() || name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+			fields = appendFields(fields, v.Field(i))
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, field{name: name, value: v.Field(i).Interface()})
+	}
+
+	return fields
+}
+
+// writeRecords - выводит записи в машиночитаемом формате с именами полей из JSON тегов
+func writeRecords[T any](w io.Writer, format outputFormat, records []T) error {
+	switch format {
+	case formatJSON:
+		return writeJSONRecords(w, records)
+	case formatCSV:
+		return writeCSVRecords(w, records)
+	case formatYAML:
+		return writeYAMLRecords(w, records)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+func writeJSONRecords[T any](w io.Writer, records []T) error {
+	if records == nil {
+		records = []T{}
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func writeCSVRecords[T any](w io.Writer, records []T) error {
+	writer := csv.NewWriter(w)
+
+	var zero T
+	header := recordFields(zero)
+	names := make([]string, 0, len(header))
+	for _, f := range header {
+		names = append(names, f.name)
+	}
+	if err := writer.Write(names); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		fields := recordFields(record)
+		row := make([]string, 0, len(fields))
+		for _, f := range fields {
+			value, err := csvValue(f.value)
+			if err != nil {
+				return err
+			}
+			row = append(row, value)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeYAMLRecords[T any](w io.Writer, records []T) error {
+	if len(records) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+
+	for _, record := range records {
+		for i, f := range recordFields(record) {
+			prefix := "  "
+			if i == 0 {
+				prefix = "- "
+			}
+
+			// JSON представление значения является допустимым YAML скаляром или коллекцией
+			value, err := json.Marshal(f.value)
+			if err != nil {
+				return err
+			}
+			if _, err = fmt.Fprintf(w, "%s%s: %s\n", prefix, f.name, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// csvValue - приводит значение поля к строке ячейки CSV
+func csvValue(value any) (string, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	switch x := v.Interface().(type) {
+	case time.Time:
+		return x.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return x.String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	default:
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
@@ -0,0 +1,191 @@
+package commands
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/service"
+)
+
+// testRecord - запись с полями всех видов, которые встречаются в выводе списков
+type testRecord struct {
+	ID       int64      `json:"id"`
+	Name     string     `json:"name"`
+	Weight   float64    `json:"weight"`
+	Note     *string    `json:"note,omitempty"`
+	At       *time.Time `json:"at,omitempty"`
+	Secret   string     `json:"-"`
+	Untagged bool
+	internal int
+}
+
+func TestExtractFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		want     outputFormat
+		wantRest []string
+		wantErr  error
+	}{
+		{name: "по умолчанию", args: []string{"list_returns", "pageSize", "5"}, want: formatTable, wantRest: []string{"list_returns", "pageSize", "5"}},
+		{name: "через пробел", args: []string{"order_history", "--format", "csv"}, want: formatCSV, wantRest: []string{"order_history"}},
+		{name: "через равно и регистр", args: []string{"--format=YAML", "order_history"}, want: formatYAML, wantRest: []string{"order_history"}},
+		{name: "без значения", args: []string{"order_history", "--format"}, wantErr: ErrUnknownFormat},
+		{name: "неизвестный формат", args: []string{"order_history", "--format", "xml"}, wantErr: ErrUnknownFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, err := extractFormat(tt.args, formatTable)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ошибка = %v, ожидалась %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got != tt.want || !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Errorf("формат %q, аргументы %v, ожидалось %q, %v", got, rest, tt.want, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestWriteCSVRecords(t *testing.T) {
+	at := time.Date(2030, 2, 20, 12, 0, 0, 0, time.UTC)
+	note := "хрупкое"
+	records := []testRecord{
+		{ID: 1, Name: `коробка, "большая"`, Weight: 1.5, Note: &note, At: &at, Secret: "скрыто", Untagged: true},
+		{ID: 2, Name: "пакет\nс переносом"},
+	}
+
+	var out strings.Builder
+	if err := writeRecords(&out, formatCSV, records); err != nil {
+		t.Fatal(err)
+	}
+
+	// столбцы идут в порядке полей структуры, поля с тегом "-" и неэкспортируемые пропускаются;
+	// запятые, кавычки и переносы строк экранируются, nil указатели дают пустые ячейки
+	want := "id,name,weight,note,at,Untagged\n" +
+		`1,"коробка, ""большая""",1.5,хрупкое,2030-02-20T12:00:00Z,true` + "\n" +
+		"2,\"пакет\nс переносом\",0,,,false\n"
+	if got := out.String(); got != want {
+		t.Errorf("вывод:\n%s\nожидалось:\n%s", got, want)
+	}
+}
+
+func TestWriteCSVRecordsEmpty(t *testing.T) {
+	var out strings.Builder
+	if err := writeRecords[testRecord](&out, formatCSV, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := out.String(), "id,name,weight,note,at,Untagged\n"; got != want {
+		t.Errorf("вывод %q, ожидался только заголовок %q", got, want)
+	}
+}
+
+func TestWriteYAMLRecords(t *testing.T) {
+	note := "yes"
+	records := []testRecord{
+		{ID: 1, Name: "key: value # не комментарий", Note: &note},
+		{ID: 2, Name: ""},
+	}
+
+	var out strings.Builder
+	if err := writeRecords(&out, formatYAML, records); err != nil {
+		t.Fatal(err)
+	}
+
+	// строки всегда в кавычках, поэтому двоеточия, решетки и слова вроде yes не меняют смысл значения
+	want := "- id: 1\n" +
+		"  name: \"key: value # не комментарий\"\n" +
+		"  weight: 0\n" +
+		"  note: \"yes\"\n" +
+		"  at: null\n" +
+		"  Untagged: false\n" +
+		"- id: 2\n" +
+		"  name: \"\"\n" +
+		"  weight: 0\n" +
+		"  note: null\n" +
+		"  at: null\n" +
+		"  Untagged: false\n"
+	if got := out.String(); got != want {
+		t.Errorf("вывод:\n%s\nожидалось:\n%s", got, want)
+	}
+}
+
+func TestWriteRecordsEmpty(t *testing.T) {
+	tests := []struct {
+		format outputFormat
+		want   string
+	}{
+		{format: formatJSON, want: "[]\n"},
+		{format: formatYAML, want: "[]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var out strings.Builder
+			if err := writeRecords[testRecord](&out, tt.format, nil); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("вывод %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteRecordsUnknownFormat(t *testing.T) {
+	var out strings.Builder
+	if err := writeRecords(&out, formatTable, []testRecord{{ID: 1}}); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("ошибка = %v, ожидалась %v", err, ErrUnknownFormat)
+	}
+}
+
+func TestWriteRecordsOmitsPickupCodeHash(t *testing.T) {
+	const hash = "0011223344556677$deadbeef"
+	orders := []model.Order{{ID: 1, CustomerID: 2, State: model.StateAccepted, PickupCodeHash: hash}}
+
+	for _, format := range []outputFormat{formatJSON, formatCSV, formatYAML} {
+		t.Run(string(format), func(t *testing.T) {
+			var out strings.Builder
+			if err := writeRecords(&out, format, orders); err != nil {
+				t.Fatal(err)
+			}
+
+			got := out.String()
+			if !strings.Contains(got, "customer_id") {
+				t.Fatalf("вывод без полей заказа: %s", got)
+			}
+			if strings.Contains(got, "pickup_code_hash") || strings.Contains(got, hash) {
+				t.Errorf("вывод содержит хеш кода выдачи: %s", got)
+			}
+		})
+	}
+}
+
+func TestWriteRecordsFlattensEmbeddedStruct(t *testing.T) {
+	listing := []service.OrderListing{{Order: model.Order{ID: 1, CustomerID: 2}, AccruedStorageFee: 30}}
+
+	var out strings.Builder
+	if err := writeRecords(&out, formatCSV, listing); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("строк %d, ожидалось 2: %s", len(lines), out.String())
+	}
+	header, row := strings.Split(lines[0], ","), strings.Split(lines[1], ",")
+	if header[0] != "id" || header[len(header)-1] != "accrued_storage_fee" {
+		t.Errorf("заголовок %v", header)
+	}
+	if row[0] != "1" || row[len(row)-1] != "30" {
+		t.Errorf("строка %v", row)
+	}
+}
@@ -2,15 +2,21 @@ package commands
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
 	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/packaging"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+	"gitlab.ozon.dev/gojhw1/pkg/service"
 	"golang.org/x/term"
 )
 
@@ -21,7 +27,7 @@ type acceptOrderParams struct {
 	weight      float64
 	cost        float64
 	packageType *model.PackageType
-	wrapper     *model.WrapperType
+	wrappers    []model.WrapperType
 }
 
 func validateAcceptOrderArgs(args []string) error {
@@ -31,7 +37,8 @@ func validateAcceptOrderArgs(args []string) error {
 	return nil
 }
 
-func parseAcceptOrderParams(args []string) (*acceptOrderParams, error) {
+// parseAcceptOrderParams - разбирает аргументы accept_order, срок хранения разбирается parseDeadline
+func parseAcceptOrderParams(args []string, parseDeadline func(string) (time.Time, error)) (*acceptOrderParams, error) {
 	orderID, err := strconv.ParseInt(args[0], 10, 64)
 	if err != nil {
 		return nil, fmt.Errorf("неверный формат orderID: %v", err)
@@ -63,7 +70,7 @@ func parseAcceptOrderParams(args []string) (*acceptOrderParams, error) {
 		return nil, errors.New("стоимость должна быть больше 0")
 	}
 
-	packageType, wrapper := parsePackageInfo(args)
+	packageType, wrappers := parsePackageInfo(args)
 
 	return &acceptOrderParams{
 		orderID:     orderID,
@@ -72,23 +79,63 @@ func parseAcceptOrderParams(args []string) (*acceptOrderParams, error) {
 		weight:      weight,
 		cost:        cost,
 		packageType: packageType,
-		wrapper:     wrapper,
+		wrappers:    wrappers,
 	}, nil
 }
 
-func parseDeadline(deadlineStr string) (time.Time, error) {
-	if dur, err := time.ParseDuration(deadlineStr); err == nil {
-		return time.Now().Add(dur), nil
+// Необязательные аргументы accept_order
+const (
+	dimensionsFlag = "--dims"
+	fragileFlag    = "--fragile"
+	promoFlag      = "--promo"
+)
+
+// extractAcceptOptions - извлекает из аргументов accept_order необязательные параметры заказа:
+// --dims <Д>x<Ш>x<В>, --fragile и --promo <код>. Значение можно указать и через "=": --dims=30x20x10
+func extractAcceptOptions(args []string) (service.AcceptOptions, []string, error) {
+	var opts service.AcceptOptions
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		switch name {
+		case fragileFlag:
+			opts.Fragile = !hasValue || value == "true"
+		case dimensionsFlag, promoFlag:
+			if !hasValue {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("%w: не указано значение %s", ErrInvalidAcceptOrderArgs, name)
+				}
+				i++
+				value = args[i]
+			}
+			if err := setAcceptOption(&opts, name, value); err != nil {
+				return opts, nil, err
+			}
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+
+	return opts, rest, nil
+}
+
+func setAcceptOption(opts *service.AcceptOptions, name, value string) error {
+	if name == promoFlag {
+		opts.PromoCode = value
+		return nil
 	}
 
-	deadline, err := time.Parse(timeLayout, deadlineStr)
+	dimensions, err := model.ParseDimensions(value)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("неверный формат даты или длительности: %v", err)
+		return err
 	}
-	return deadline, nil
+	opts.Dimensions = &dimensions
+
+	return nil
 }
 
-func parsePackageInfo(args []string) (*model.PackageType, *model.WrapperType) {
+// parsePackageInfo - разбирает упаковку с обертками вида package_type[+wrapper...]: "box+film+bubble"
+func parsePackageInfo(args []string) (*model.PackageType, []model.WrapperType) {
 	if len(args) <= 5 {
 		return nil, nil
 	}
@@ -97,16 +144,17 @@ func parsePackageInfo(args []string) (*model.PackageType, *model.WrapperType) {
 	pt := model.PackageType(strings.TrimSpace(parts[0]))
 	packageType := &pt
 
-	var wrapper *model.WrapperType
-	if len(parts) > 1 {
-		wt := model.WrapperType(strings.TrimSpace(parts[1]))
-		wrapper = &wt
+	var wrappers []model.WrapperType
+	for _, part := range parts[1:] {
+		wrappers = append(wrappers, model.WrapperType(strings.TrimSpace(part)))
 	}
 
-	return packageType, wrapper
+	return packageType, wrappers
 }
 
-func listReturnsPrintFull(returns []model.Order, pageSize int) error {
+// listReturnsPrintFull - выводит возвраты постранично. В интерактивном режиме
+// перед каждой следующей страницей ожидается нажатие Enter
+func listReturnsPrintFull(returns []model.Order, pageSize int, interactive bool) error {
 	totalReturns := len(returns)
 	totalPages := (totalReturns + pageSize - 1) / pageSize
 	reader := bufio.NewReader(os.Stdin)
@@ -121,7 +169,7 @@ func listReturnsPrintFull(returns []model.Order, pageSize int) error {
 
 		fmt.Printf("Страница %d из %d (Всего возвратов: %d)\n", i+1, totalPages, totalReturns)
 
-		if i < totalPages-1 {
+		if interactive && i < totalPages-1 {
 			fmt.Print("Нажмите Enter для следующей страницы...")
 			_, err := reader.ReadString('\n')
 			if err != nil {
@@ -243,41 +291,48 @@ func processLOPageSize(args []string, i int, params *listOrdersParams) (int, err
 func displayLOOrders(h *Handler, terminal *term.Terminal, ordersList []model.Order, currentPos int, totalOrders, pageSize int) error {
 	h.clearTerminal()
 
-	w := tabwriter.NewWriter(terminal, 0, 0, 2, ' ', 0)
-
 	if _, err := fmt.Fprintln(terminal, "\t\t\t\t=== Список заказов ==="); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintln(w, "ID\tКлиент\tСрок хранения\tСостояние\tЦена\tВес\tУпаковка\tОбновлен"); err != nil {
+
+	end := min(currentPos+pageSize, totalOrders)
+	if err := writeLOTable(terminal, ordersList[currentPos:end], h.service.StorageFee); err != nil {
 		return err
 	}
 
-	end := min(currentPos+pageSize, totalOrders)
+	if err := displayLOControls(terminal, currentPos, totalOrders, pageSize, end); err != nil {
+		return err
+	}
 
-	for i := currentPos; i < end; i++ {
-		order := ordersList[i]
+	return displayLOProgressBar(terminal, currentPos, pageSize, totalOrders)
+}
 
-		if _, err := fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%.2f\t%.2f\t%s\t%s\n",
+// writeLOTable - выводит таблицу заказов для list_orders. storageFee - плата за хранение заказа,
+// накопленная к текущему моменту или начисленная при выдаче
+func writeLOTable(out io.Writer, orders []model.Order, storageFee func(model.Order) float64) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(w, "ID\tКлиент\tСрок хранения\tСостояние\tЯчейка\tЦена\tХранение\tВес\tУпаковка\tОбновлен"); err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		if _, err := fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%.2f\t%.2f\t%.2f\t%s\t%s\n",
 			order.ID,
 			order.CustomerID,
 			order.DeadlineAt.Format(timeLayout),
 			order.State,
+			formatCell(order.Cell),
 			order.Cost,
+			storageFee(order),
 			order.Weight,
 			formatPackageInfo(order),
 			order.UpdatedAt.Format(timeLayout)); err != nil {
 			return err
 		}
 	}
-	if err := w.Flush(); err != nil {
-		return err
-	}
-
-	if err := displayLOControls(terminal, currentPos, totalOrders, pageSize, end); err != nil {
-		return err
-	}
 
-	return displayLOProgressBar(terminal, currentPos, pageSize, totalOrders)
+	return w.Flush()
 }
 
 func displayLOControls(terminal *term.Terminal, currentPos int, totalOrders, pageSize, end int) error {
@@ -374,9 +429,520 @@ func formatPackageInfo(order model.Order) string {
 		return "-"
 	}
 	result := string(*order.PackageType)
-	if order.Wrapper != nil {
-		result += " + " + string(*order.Wrapper)
+	for _, wrapper := range order.Wrappers {
+		result += " + " + string(wrapper)
 	}
 
 	return result
 }
+
+func formatMaxWeight(maxWeight float64) string {
+	if maxWeight <= 0 {
+		return "без ограничения"
+	}
+
+	return strconv.FormatFloat(maxWeight, 'f', 2, 64)
+}
+
+// writePackaging - выводит строки таблицы типов упаковки и оберток
+func writePackaging(w io.Writer, packages []packaging.Package, wrappers []packaging.Wrapper) error {
+	if _, err := fmt.Fprintln(w, "Упаковка\tСтоимость\tЗа кг\tМакс. вес\tМакс. габариты, см\tДелитель объемного веса"); err != nil {
+		return fmt.Errorf("ошибка при записи заголовка: %v", err)
+	}
+	for _, pkg := range packages {
+		dimensions := "без ограничения"
+		if pkg.MaxDimensions != nil {
+			dimensions = pkg.MaxDimensions.String()
+		}
+		divisor := "-"
+		if pkg.VolumetricDivisor > 0 {
+			divisor = strconv.FormatFloat(pkg.VolumetricDivisor, 'f', -1, 64)
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%s\t%s\t%s\n", pkg.Name, pkg.Cost, pkg.CostPerKg,
+			formatMaxWeight(pkg.MaxWeight), dimensions, divisor); err != nil {
+			return fmt.Errorf("ошибка при записи данных: %v", err)
+		}
+	}
+
+	if _, err := fmt.Fprintln(w, "\nОбертка\tСтоимость\tИзменение макс. веса\t\t\t"); err != nil {
+		return fmt.Errorf("ошибка при записи заголовка: %v", err)
+	}
+	for _, wrapper := range wrappers {
+		if _, err := fmt.Fprintf(w, "%s\t%.2f\t%+.2f\t\t\t\n", wrapper.Name, wrapper.Cost, wrapper.MaxWeightAdjustment); err != nil {
+			return fmt.Errorf("ошибка при записи данных: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// writeSuggestion - выводит рекомендуемую упаковку и все рассмотренные варианты
+func writeSuggestion(out io.Writer, suggestion service.PackageSuggestion) error {
+	if suggestion.Best == nil {
+		if _, err := fmt.Fprintln(out, "Ни один тип упаковки не подходит для заказа."); err != nil {
+			return err
+		}
+	} else if _, err := fmt.Fprintf(out, "Рекомендуемая упаковка: %s, стоимость %.2f\n", suggestion.Best, suggestion.Best.Cost); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "\nУпаковка\tСтоимость\tРезультат"); err != nil {
+		return fmt.Errorf("ошибка при записи заголовка: %v", err)
+	}
+	for _, option := range suggestion.Options {
+		cost, result := fmt.Sprintf("%.2f", option.Cost), "подходит"
+		switch {
+		case !option.Valid():
+			cost, result = "-", option.Reason
+		case suggestion.Best != nil && option.String() == suggestion.Best.String():
+			result = "рекомендуется"
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", option, cost, result); err != nil {
+			return fmt.Errorf("ошибка при записи данных: %v", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("ошибка при выводе таблицы: %v", err)
+	}
+
+	return nil
+}
+
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
+
+// writeEventsTable - выводит таблицу событий заказа
+func writeEventsTable(out io.Writer, events []model.OrderEvent) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(w, "#\tВремя\tКто\tИз\tВ\tКоманда\tПричина"); err != nil {
+		return fmt.Errorf("ошибка при записи заголовка: %v", err)
+	}
+
+	for _, event := range events {
+		if _, err := fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			event.ID,
+			event.At.Format(timeLayout),
+			event.Actor,
+			formatState(event.FromState),
+			formatState(event.ToState),
+			event.Command,
+			event.Reason); err != nil {
+			return fmt.Errorf("ошибка при записи данных: %v", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("ошибка при выводе таблицы: %v", err)
+	}
+
+	return nil
+}
+
+// writePriceBreakdown - выводит строки разбивки стоимости заказа и итог
+func writePriceBreakdown(out io.Writer, breakdown service.PriceBreakdown) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(w, "Правило\tОписание\tСумма"); err != nil {
+		return fmt.Errorf("ошибка при записи заголовка: %v", err)
+	}
+
+	for _, line := range breakdown.Lines {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%.2f\n", line.Rule, line.Description, line.Amount); err != nil {
+			return fmt.Errorf("ошибка при записи данных: %v", err)
+		}
+	}
+	if _, err := fmt.Fprintf(w, "\tИтого\t%.2f\n", breakdown.Total); err != nil {
+		return fmt.Errorf("ошибка при записи данных: %v", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("ошибка при выводе таблицы: %v", err)
+	}
+
+	return nil
+}
+
+func formatState(state model.OrderState) string {
+	if state == "" {
+		return "-"
+	}
+
+	return string(state)
+}
+
+// acceptFileParams - параметры команды accept_orders_file
+type acceptFileParams struct {
+	filename   string
+	mode       service.BatchMode
+	reportPath string
+	importOpts service.ImportOptions
+
+	stream          bool
+	resume          bool
+	checkpointPath  string
+	checkpointEvery int
+}
+
+// acceptFileBoolFlags - флаги команды accept_orders_file без значения
+var acceptFileBoolFlags = map[string]func(p *acceptFileParams){
+	"--atomic":      func(p *acceptFileParams) { p.mode = service.BatchAtomic },
+	"--best-effort": func(p *acceptFileParams) { p.mode = service.BatchBestEffort },
+	"--stream":      func(p *acceptFileParams) { p.stream = true },
+	"--resume":      func(p *acceptFileParams) { p.stream, p.resume = true, true },
+}
+
+// parseAcceptFileParams - разбирает аргументы команды accept_orders_file
+func parseAcceptFileParams(args []string) (acceptFileParams, error) {
+	var params acceptFileParams
+
+	var inputFormat, delimiter, columns, checkpointEvery string
+	valueFlags := map[string]*string{
+		"--report":           &params.reportPath,
+		"--input-format":     &inputFormat,
+		"--delimiter":        &delimiter,
+		"--columns":          &columns,
+		"--checkpoint":       &params.checkpointPath,
+		"--checkpoint-every": &checkpointEvery,
+	}
+
+	for i := 0; i < len(args); i++ {
+		if value, ok := valueFlags[args[i]]; ok {
+			if i+1 >= len(args) {
+				return params, ErrInvalidAcceptFileArgs
+			}
+			i++
+			*value = args[i]
+			continue
+		}
+		if set, ok := acceptFileBoolFlags[args[i]]; ok {
+			set(&params)
+			continue
+		}
+
+		if params.filename != "" || strings.HasPrefix(args[i], "--") {
+			return params, ErrInvalidAcceptFileArgs
+		}
+		params.filename = args[i]
+	}
+	if params.filename == "" {
+		return params, ErrInvalidAcceptFileArgs
+	}
+
+	if err := params.setStreamDefaults(checkpointEvery); err != nil {
+		return params, err
+	}
+
+	var err error
+	params.importOpts, err = service.ParseImportOptions(inputFormat, delimiter, columns)
+
+	return params, err
+}
+
+// setStreamDefaults - проверяет параметры потокового приема и заполняет значения по умолчанию
+func (p *acceptFileParams) setStreamDefaults(checkpointEvery string) error {
+	if !p.stream {
+		if p.mode == "" {
+			p.mode = service.BatchAtomic
+		}
+		return nil
+	}
+
+	if p.mode == service.BatchAtomic {
+		return ErrStreamAtomic
+	}
+	p.mode = service.BatchBestEffort
+
+	if p.checkpointPath == "" {
+		p.checkpointPath = p.filename + checkpointSuffix
+	}
+
+	p.checkpointEvery = service.DefaultCheckpointEvery
+	if checkpointEvery != "" {
+		every, err := strconv.Atoi(checkpointEvery)
+		if err != nil || every <= 0 {
+			return fmt.Errorf("неверное значение --checkpoint-every: %s", checkpointEvery)
+		}
+		p.checkpointEvery = every
+	}
+
+	return nil
+}
+
+// printStreamProgress - выводит ход потокового приема в stderr.
+// В интерактивном режиме строка прогресса перезаписывается
+func printStreamProgress(progress service.StreamProgress, interactive bool) {
+	end := "\n"
+	if interactive {
+		end = ""
+		fmt.Fprint(os.Stderr, "\r")
+	}
+
+	percent := 100.0
+	if progress.TotalBytes > 0 {
+		percent = float64(progress.BytesRead) * 100 / float64(progress.TotalBytes)
+	}
+
+	fmt.Fprintf(os.Stderr, "Обработано записей: %d (принято %d, отклонено %d), прочитано %.1f%%%s",
+		progress.Records, progress.Accepted, progress.Rejected, percent, end)
+}
+
+// openStreamReport - открывает файл отчета потокового приема, куда результаты дописываются по одному
+// JSON объекту на строку. При продолжении приема файл дополняется, иначе перезаписывается
+func openStreamReport(path string, resume bool) (*os.File, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if !resume {
+		flags |= os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия файла отчета: %v", err)
+	}
+
+	return file, nil
+}
+
+// writeBatchReport - выводит результат пакетного приема: таблицу с итогами или записи в формате format
+func writeBatchReport(out io.Writer, format outputFormat, report *service.BatchReport) error {
+	if format != formatTable {
+		return writeRecords(out, format, report.Results)
+	}
+	if report.Results == nil {
+		return writeBatchSummary(out, report)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "Строка\tID\tРезультат\tОшибка"); err != nil {
+		return fmt.Errorf("ошибка при записи заголовка: %v", err)
+	}
+	for _, result := range report.Results {
+		if _, err := fmt.Fprintf(w, "%d\t%d\t%s\t%s\n", result.Line, result.OrderID, result.Status, result.Error); err != nil {
+			return fmt.Errorf("ошибка при записи данных: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("ошибка при выводе таблицы: %v", err)
+	}
+
+	return writeBatchSummary(out, report)
+}
+
+// writeBatchSummary - выводит итоги пакетного приема
+func writeBatchSummary(out io.Writer, report *service.BatchReport) error {
+	_, err := fmt.Fprintf(out, "Режим: %s. Всего: %d, принято: %d, отклонено: %d, пропущено: %d\n",
+		report.Mode, report.Total, report.Accepted, report.Rejected, report.Skipped)
+	return err
+}
+
+// writeBatchReportFile - сохраняет отчет о пакетном приеме в JSON файл
+func writeBatchReportFile(path string, report *service.BatchReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка формирования отчета: %v", err)
+	}
+	if err = os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("ошибка записи отчета: %v", err)
+	}
+
+	return nil
+}
+
+func formatCell(cell string) string {
+	if cell == "" {
+		return "-"
+	}
+
+	return cell
+}
+
+// writePolicyCells - выводит стеллажи с ячейками хранения из политики
+func writePolicyCells(w io.Writer, groups []policy.CellGroup) error {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintln(w, "\nСтеллаж\tЯчеек\tВместимость ячейки\tУпаковка"); err != nil {
+		return fmt.Errorf("ошибка при записи заголовка: %v", err)
+	}
+	for _, group := range groups {
+		packages := "любая"
+		if len(group.PackageTypes) > 0 {
+			names := make([]string, 0, len(group.PackageTypes))
+			for _, packageType := range group.PackageTypes {
+				names = append(names, string(packageType))
+			}
+			packages = strings.Join(names, ", ")
+		}
+
+		if _, err := fmt.Fprintf(w, "%s\t%d\t%.2f кг\t%s\n", group.Shelf, group.Count, group.MaxWeight, packages); err != nil {
+			return fmt.Errorf("ошибка при записи данных: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// capacityRow - строка таблицы загрузки ПВЗ
+type capacityRow struct {
+	name        string
+	used, limit float64
+	format      string
+}
+
+// writeCapacity - выводит таблицу загрузки ПВЗ относительно лимитов вместимости
+func writeCapacity(out io.Writer, usage service.Utilization, limits policy.CapacitySpec) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "Показатель\tЗанято\tЛимит\tЗагрузка"); err != nil {
+		return fmt.Errorf("ошибка при записи заголовка: %v", err)
+	}
+
+	rows := []capacityRow{
+		{"Заказы", float64(usage.Orders), float64(limits.MaxOrders), "%.0f"},
+		{"Вес, кг", usage.Weight, limits.MaxWeight, "%.2f"},
+	}
+
+	packages := make(map[model.PackageType]bool)
+	for name := range usage.ByPackage {
+		packages[name] = true
+	}
+	for name := range limits.MaxByPackage {
+		packages[name] = true
+	}
+	for _, name := range sortedKeys(packages) {
+		rows = append(rows, capacityRow{"Упаковка " + string(name), float64(usage.ByPackage[name]), float64(limits.MaxByPackage[name]), "%.0f"})
+	}
+
+	for _, row := range rows {
+		if _, err := fmt.Fprintf(w, "%s\t"+row.format+"\t%s\t%s\n",
+			row.name, row.used, formatLimit(row.limit, row.format), formatLoad(row.used, row.limit)); err != nil {
+			return fmt.Errorf("ошибка при записи данных: %v", err)
+		}
+	}
+
+	if usage.CellsTotal > 0 {
+		if _, err := fmt.Fprintf(w, "Ячейки\t%d\t%d\t%s\n",
+			usage.CellsUsed, usage.CellsTotal, formatLoad(float64(usage.CellsUsed), float64(usage.CellsTotal))); err != nil {
+			return fmt.Errorf("ошибка при записи данных: %v", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("ошибка при выводе таблицы: %v", err)
+	}
+
+	return nil
+}
+
+func formatLimit(limit float64, format string) string {
+	if limit <= 0 {
+		return "без ограничения"
+	}
+
+	return fmt.Sprintf(format, limit)
+}
+
+// formatDurationLimit - выводит ограничение по длительности, 0 - без ограничения
+func formatDurationLimit(limit time.Duration) string {
+	if limit <= 0 {
+		return "без ограничения"
+	}
+
+	return limit.String()
+}
+
+func formatLoad(used, limit float64) string {
+	if limit <= 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.1f%%", used*100/limit)
+}
+
+// writePointsTable - выводит пункты выдачи с их загрузкой, отмечая текущий пункт
+func writePointsTable(out io.Writer, points []service.PointSummary, current string) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "\tID\tНазвание\tАдрес\tЗаказы\tВес, кг"); err != nil {
+		return fmt.Errorf("ошибка при записи заголовка: %v", err)
+	}
+
+	for _, point := range points {
+		mark := ""
+		if point.ID == current {
+			mark = "*"
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%.2f\n",
+			mark, point.ID, point.Name, point.Address, point.Usage.Orders, point.Usage.Weight); err != nil {
+			return fmt.Errorf("ошибка при записи данных: %v", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("ошибка при выводе таблицы: %v", err)
+	}
+
+	return nil
+}
+
+// writeCharges - выводит разбивку суммы к оплате за заказ
+func writeCharges(out io.Writer, charges service.Charges) error {
+	storage := fmt.Sprintf("%.2f", charges.Storage)
+	if charges.StorageDays > 0 {
+		storage += fmt.Sprintf(" (%d сут.)", charges.StorageDays)
+	}
+
+	rows := [][2]string{
+		{"Стоимость заказа:", fmt.Sprintf("%.2f", charges.Order)},
+		{"Упаковка:", fmt.Sprintf("%.2f", charges.Packaging)},
+		{"Платное хранение:", storage},
+		{"Итого:", fmt.Sprintf("%.2f", charges.Total)},
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintf(out, "  %-18s %s\n", row[0], row[1]); err != nil {
+			return fmt.Errorf("ошибка при записи данных: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// parseHandouts - разбирает заказы к выдаче в виде <orderID>:<code>
+func parseHandouts(args []string) ([]service.Handout, error) {
+	handouts := make([]service.Handout, 0, len(args))
+	for _, arg := range args {
+		idStr, code, ok := strings.Cut(arg, ":")
+		if !ok || code == "" {
+			return nil, fmt.Errorf("%w: %s", ErrMissingPickupCode, arg)
+		}
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("неверный формат orderID: %w", err)
+		}
+		handouts = append(handouts, service.Handout{OrderID: id, Code: code})
+	}
+
+	return handouts, nil
+}
+
+// parseOrderIDs - разбирает список ID заказов
+func parseOrderIDs(args []string) ([]int64, error) {
+	ids := make([]int64, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("неверный формат orderID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.ozon.dev/gojhw1/pkg/packaging"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+func TestWritePackagingListsRegisteredTypes(t *testing.T) {
+	p := policy.Default()
+	p.Packages["envelope"] = policy.PackageSpec{Cost: 2, MaxWeight: 0.5}
+	p.Wrappers["bubble"] = policy.WrapperSpec{Cost: 3}
+	r := packaging.FromPolicy(p)
+
+	var out strings.Builder
+	if err := writePackaging(&out, r.Packages(), r.Wrappers()); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(out.String(), "\n")
+	for _, name := range []string{"bag", "box", "envelope", "film", "bubble"} {
+		found := false
+		for _, line := range lines {
+			found = found || strings.HasPrefix(line, name+"\t")
+		}
+		if !found {
+			t.Errorf("нет строки %s:\n%s", name, out.String())
+		}
+	}
+}
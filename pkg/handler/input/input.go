@@ -39,7 +39,16 @@ func (h *Handler) ReadLine() (string, error) {
 
 // ProcessLine - обрабатывает строку, полученную из ReadLine
 func (h *Handler) ProcessLine(line string) (string, []string) {
+	return ParseLine(line)
+}
+
+// ParseLine - разбивает строку на команду и аргументы.
+// Для пустой строки и комментария (начинается с #) возвращает пустую команду
+func ParseLine(line string) (string, []string) {
 	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "#") {
+		return "", nil
+	}
 	if line == "" {
 		return "", nil
 	}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidDimensions = errors.New("габариты должны быть заданы как ДxШxВ в см, каждое измерение больше 0")
+)
+
+// Dimensions - габариты в сантиметрах
+type Dimensions struct {
+	Length float64 `json:"length"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// ParseDimensions - разбирает габариты вида "30x20x10" (допускается и русская "х")
+func ParseDimensions(s string) (Dimensions, error) {
+	parts := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return r == 'x' || r == 'х' || r == '*'
+	})
+	if len(parts) != 3 {
+		return Dimensions{}, fmt.Errorf("%w: %q", ErrInvalidDimensions, s)
+	}
+
+	var values [3]float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(strings.ReplaceAll(part, ",", ".")), 64)
+		if err != nil {
+			return Dimensions{}, fmt.Errorf("%w: %q", ErrInvalidDimensions, s)
+		}
+		values[i] = value
+	}
+
+	d := Dimensions{Length: values[0], Width: values[1], Height: values[2]}
+	if !d.Valid() {
+		return Dimensions{}, fmt.Errorf("%w: %q", ErrInvalidDimensions, s)
+	}
+
+	return d, nil
+}
+
+// Valid - проверяет, что все измерения больше 0
+func (d Dimensions) Valid() bool {
+	return d.Length > 0 && d.Width > 0 && d.Height > 0
+}
+
+// Volume - объем в кубических сантиметрах
+func (d Dimensions) Volume() float64 {
+	return d.Length * d.Width * d.Height
+}
+
+// FitsIn - проверяет, помещается ли предмет с габаритами d в габариты limit.
+// Предмет можно повернуть, поэтому сравниваются измерения, упорядоченные по возрастанию
+func (d Dimensions) FitsIn(limit Dimensions) bool {
+	item, box := d.sorted(), limit.sorted()
+	for i := range item {
+		if item[i] > box[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String - выводит габариты в виде "ДxШxВ"
+func (d Dimensions) String() string {
+	return fmt.Sprintf("%gx%gx%g", d.Length, d.Width, d.Height)
+}
+
+func (d Dimensions) sorted() []float64 {
+	values := []float64{d.Length, d.Width, d.Height}
+	slices.Sort(values)
+
+	return values
+}
@@ -1,19 +1,91 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 )
 
 type Order struct {
-	ID          int64        `json:"id"`
-	CustomerID  int64        `json:"customer_id"`
-	State       OrderState   `json:"state"`
-	Weight      float64      `json:"weight"`
-	Cost        float64      `json:"cost"`
-	PackageType *PackageType `json:"package_type,omitempty"`
-	Wrapper     *WrapperType `json:"wrapper,omitempty"`
-	DeadlineAt  time.Time    `json:"deadline_at"`
-	UpdatedAt   time.Time    `json:"updated_at"`
-	DeliveredAt *time.Time   `json:"delivered_at,omitempty"`
-	ReturnedAt  *time.Time   `json:"returned_at,omitempty"`
+	ID                  int64         `json:"id"`
+	CustomerID          int64         `json:"customer_id"`
+	PointID             string        `json:"point_id,omitempty"`
+	State               OrderState    `json:"state"`
+	Weight              float64       `json:"weight"`
+	Dimensions          *Dimensions   `json:"dimensions,omitempty"`
+	Cost                float64       `json:"cost"`
+	PackagingCost       float64       `json:"packaging_cost,omitempty"`
+	StorageFee          float64       `json:"storage_fee,omitempty"`
+	Fragile             bool          `json:"fragile,omitempty"`
+	PromoCode           string        `json:"promo_code,omitempty"`
+	PriceBreakdown      []PriceLine   `json:"price_breakdown,omitempty"`
+	PackageType         *PackageType  `json:"package_type,omitempty"`
+	Wrappers            []WrapperType `json:"wrappers,omitempty"`
+	Cell                string        `json:"cell,omitempty"`
+	DeadlineAt          time.Time     `json:"deadline_at"`
+	Extensions          int           `json:"extensions,omitempty"`
+	ExtendedFor         time.Duration `json:"extended_for,omitempty"`
+	PickupCodeHash      string        `json:"-"`
+	PickupAttempts      int           `json:"pickup_attempts,omitempty"`
+	PickupLockedUntil   *time.Time    `json:"pickup_locked_until,omitempty"`
+	UpdatedAt           time.Time     `json:"updated_at"`
+	AcceptedAt          *time.Time    `json:"accepted_at,omitempty"`
+	DeliveredAt         *time.Time    `json:"delivered_at,omitempty"`
+	ReturnedAt          *time.Time    `json:"returned_at,omitempty"`
+	ReturnedToCourierAt *time.Time    `json:"returned_to_courier_at,omitempty"`
+}
+
+// UnmarshalJSON - разбирает заказ из JSON. Заказы, сохраненные до поддержки нескольких оберток,
+// хранят единственную обертку в поле wrapper - она становится первой в списке Wrappers
+func (o *Order) UnmarshalJSON(data []byte) error {
+	type plainOrder Order
+	aux := struct {
+		*plainOrder
+		Wrapper *WrapperType `json:"wrapper,omitempty"`
+	}{plainOrder: (*plainOrder)(o)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Wrapper != nil && len(o.Wrappers) == 0 {
+		o.Wrappers = []WrapperType{*aux.Wrapper}
+	}
+
+	return nil
+}
+
+// PriceLine - строка разбивки стоимости заказа: правило и его вклад в стоимость, скидки отрицательные
+type PriceLine struct {
+	Rule        string  `json:"rule"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+}
+
+// OrderEvent - неизменяемая запись о смене состояния заказа
+type OrderEvent struct {
+	ID        int64      `json:"id"`
+	OrderID   int64      `json:"order_id"`
+	At        time.Time  `json:"at"`
+	Actor     string     `json:"actor"`
+	FromState OrderState `json:"from_state,omitempty"`
+	ToState   OrderState `json:"to_state,omitempty"`
+	Command   string     `json:"command"`
+	Reason    string     `json:"reason,omitempty"`
+}
+
+// PickupPoint - пункт выдачи заказов
+type PickupPoint struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// PickupNotice - код выдачи заказа для системы уведомления клиентов.
+// В заказе хранится только хеш кода, сам код есть только в уведомлении
+type PickupNotice struct {
+	OrderID    int64     `json:"order_id"`
+	CustomerID int64     `json:"customer_id"`
+	PointID    string    `json:"point_id,omitempty"`
+	Code       string    `json:"code"`
+	DeadlineAt time.Time `json:"deadline_at"`
+	IssuedAt   time.Time `json:"issued_at"`
 }
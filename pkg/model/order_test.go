@@ -0,0 +1,69 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCodeHash = "0011223344556677$deadbeef"
+
+// fullOrder - заказ, в котором заполнены все поля, чтобы omitempty не скрывал их при сериализации
+func fullOrder() Order {
+	at := time.Date(2030, 2, 20, 12, 0, 0, 0, time.UTC)
+	box := PackageBox
+
+	return Order{
+		ID: 1, CustomerID: 2, PointID: "msk-1", State: StateAccepted, Weight: 1.5,
+		Dimensions: &Dimensions{Length: 10, Width: 20, Height: 30},
+		Cost:       120, PackagingCost: 20, StorageFee: 10, Fragile: true, PromoCode: "SPRING10",
+		PriceBreakdown: []PriceLine{{Rule: "base", Description: "Стоимость", Amount: 100}},
+		PackageType:    &box, Wrappers: []WrapperType{WrapperFilm}, Cell: "A-01",
+		DeadlineAt: at, Extensions: 1, ExtendedFor: time.Hour,
+		PickupCodeHash: testCodeHash, PickupAttempts: 2, PickupLockedUntil: &at,
+		UpdatedAt: at, AcceptedAt: &at, DeliveredAt: &at, ReturnedAt: &at, ReturnedToCourierAt: &at,
+	}
+}
+
+func jsonFields(t *testing.T, v any) map[string]any {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err = json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+
+	return fields
+}
+
+func TestOrderJSONOmitsPickupCodeHash(t *testing.T) {
+	order := fullOrder()
+
+	data, err := json.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), testCodeHash) {
+		t.Fatalf("вывод содержит хеш кода выдачи: %s", data)
+	}
+	if _, ok := jsonFields(t, order)["pickup_code_hash"]; ok {
+		t.Fatalf("вывод содержит поле pickup_code_hash: %s", data)
+	}
+
+	// остальные поля заказа выводятся без потерь
+	var got Order
+	if err = json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := order
+	want.PickupCodeHash = ""
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("после разбора %+v, ожидалось %+v", got, want)
+	}
+}
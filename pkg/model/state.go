@@ -0,0 +1,69 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidTransition = errors.New("недопустимая смена состояния заказа")
+)
+
+// StateNew - состояние заказа до приема от курьера
+const StateNew OrderState = ""
+
+// transitions - допустимые переходы между состояниями заказа
+var transitions = map[OrderState][]OrderState{
+	StateNew:       {StateAccepted},
+	StateAccepted:  {StateDelivered, StateReturnedToCourier, StateInTransit},
+	StateDelivered: {StateReturned},
+	StateReturned:  {StateReturnedToCourier},
+	StateInTransit: {StateAccepted},
+}
+
+// CanTransitionTo - проверяет, допустим ли переход из состояния s в состояние to
+func (s OrderState) CanTransitionTo(to OrderState) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsTerminal - проверяет, является ли состояние конечным
+func (s OrderState) IsTerminal() bool {
+	return len(transitions[s]) == 0
+}
+
+// TransitionTo - переводит заказ в состояние to, если переход допустим, и обновляет отметки времени.
+// Когда заказ покидает ПВЗ, его ячейка хранения освобождается
+func (o *Order) TransitionTo(to OrderState, now time.Time) error {
+	if !o.State.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, o.State, to)
+	}
+
+	o.State = to
+	o.UpdatedAt = now
+	switch to {
+	case StateAccepted:
+		// при перемещении в другой пункт заказ снова принимается, но срок хранения отсчитывается с первого приема
+		if o.AcceptedAt == nil {
+			o.AcceptedAt = &now
+		}
+	case StateDelivered:
+		o.DeliveredAt = &now
+		o.Cell = ""
+	case StateReturned:
+		o.ReturnedAt = &now
+	case StateReturnedToCourier:
+		o.ReturnedToCourierAt = &now
+		o.Cell = ""
+	case StateInTransit:
+		o.Cell = ""
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var allStates = []OrderState{StateNew, StateAccepted, StateDelivered, StateReturned, StateReturnedToCourier, StateInTransit}
+
+func TestCanTransitionTo(t *testing.T) {
+	allowed := map[[2]OrderState]bool{
+		{StateNew, StateAccepted}:               true,
+		{StateAccepted, StateDelivered}:         true,
+		{StateAccepted, StateReturnedToCourier}: true,
+		{StateAccepted, StateInTransit}:         true,
+		{StateDelivered, StateReturned}:         true,
+		{StateReturned, StateReturnedToCourier}: true,
+		{StateInTransit, StateAccepted}:         true,
+	}
+
+	for _, from := range allStates {
+		for _, to := range allStates {
+			want := allowed[[2]OrderState{from, to}]
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%q -> %q: CanTransitionTo = %v, ожидалось %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	for _, state := range allStates {
+		want := state == StateReturnedToCourier
+		if got := state.IsTerminal(); got != want {
+			t.Errorf("%q: IsTerminal = %v, ожидалось %v", state, got, want)
+		}
+	}
+}
+
+func TestTransitionTo(t *testing.T) {
+	accepted := time.Date(2030, 2, 20, 12, 0, 0, 0, time.UTC)
+	now := accepted.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		order   Order
+		to      OrderState
+		wantErr bool
+		check   func(t *testing.T, o Order)
+	}{
+		{
+			name:  "прием",
+			order: Order{},
+			to:    StateAccepted,
+			check: func(t *testing.T, o Order) {
+				if o.AcceptedAt == nil || !o.AcceptedAt.Equal(now) {
+					t.Errorf("AcceptedAt = %v, ожидалось %v", o.AcceptedAt, now)
+				}
+			},
+		},
+		{
+			name:  "повторный прием после перемещения сохраняет первую отметку",
+			order: Order{State: StateInTransit, AcceptedAt: &accepted},
+			to:    StateAccepted,
+			check: func(t *testing.T, o Order) {
+				if !o.AcceptedAt.Equal(accepted) {
+					t.Errorf("AcceptedAt = %v, ожидалось %v", o.AcceptedAt, accepted)
+				}
+			},
+		},
+		{
+			name:  "выдача освобождает ячейку",
+			order: Order{State: StateAccepted, Cell: "A-1-1"},
+			to:    StateDelivered,
+			check: func(t *testing.T, o Order) {
+				if o.Cell != "" || o.DeliveredAt == nil {
+					t.Errorf("Cell = %q, DeliveredAt = %v", o.Cell, o.DeliveredAt)
+				}
+			},
+		},
+		{
+			name:  "возврат курьеру освобождает ячейку",
+			order: Order{State: StateAccepted, Cell: "A-1-1"},
+			to:    StateReturnedToCourier,
+			check: func(t *testing.T, o Order) {
+				if o.Cell != "" || o.ReturnedToCourierAt == nil {
+					t.Errorf("Cell = %q, ReturnedToCourierAt = %v", o.Cell, o.ReturnedToCourierAt)
+				}
+			},
+		},
+		{
+			name:    "выдача невыданного возврата",
+			order:   Order{State: StateReturned},
+			to:      StateDelivered,
+			wantErr: true,
+		},
+		{
+			name:    "из конечного состояния",
+			order:   Order{State: StateReturnedToCourier},
+			to:      StateAccepted,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := tt.order
+			err := order.TransitionTo(tt.to, now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTransition) {
+					t.Fatalf("ошибка = %v, ожидалась %v", err, ErrInvalidTransition)
+				}
+				if order.State != tt.order.State {
+					t.Errorf("состояние изменилось на %q при недопустимом переходе", order.State)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order.State != tt.to || !order.UpdatedAt.Equal(now) {
+				t.Errorf("State = %q, UpdatedAt = %v", order.State, order.UpdatedAt)
+			}
+			tt.check(t, order)
+		})
+	}
+}
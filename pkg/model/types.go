@@ -3,9 +3,11 @@ package model
 type OrderState string
 
 const (
-	StateAccepted  OrderState = "accepted"
-	StateDelivered OrderState = "delivered"
-	StateReturned  OrderState = "returned"
+	StateAccepted          OrderState = "accepted"
+	StateDelivered         OrderState = "delivered"
+	StateReturned          OrderState = "returned"
+	StateReturnedToCourier OrderState = "returned_to_courier"
+	StateInTransit         OrderState = "in_transit"
 )
 
 type PackageType string
@@ -14,6 +16,9 @@ const (
 	PackageBag  PackageType = "bag"
 	PackageBox  PackageType = "box"
 	PackageFilm PackageType = "film"
+
+	// PackageAuto - упаковка подбирается автоматически: самая дешевая из подходящих для заказа
+	PackageAuto PackageType = "auto"
 )
 
 type WrapperType string
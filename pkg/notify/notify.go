@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+var (
+	ErrWriteOutbox = errors.New("ошибка записи выгрузки кодов выдачи")
+)
+
+// Outbox - выгрузка кодов выдачи, из которой система уведомлений отправляет их клиентам
+type Outbox interface {
+	// Send - добавляет коды выдачи в выгрузку
+	Send(notices ...model.PickupNotice) error
+}
+
+// MemoryOutbox - выгрузка кодов выдачи в памяти
+type MemoryOutbox struct {
+	mu      sync.RWMutex
+	notices []model.PickupNotice
+}
+
+// NewMemoryOutbox - создает пустую выгрузку в памяти
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{}
+}
+
+// Send - добавляет коды выдачи в выгрузку
+func (o *MemoryOutbox) Send(notices ...model.PickupNotice) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.notices = append(o.notices, notices...)
+
+	return nil
+}
+
+// Notices - возвращает все выгруженные коды выдачи
+func (o *MemoryOutbox) Notices() []model.PickupNotice {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return append([]model.PickupNotice(nil), o.notices...)
+}
+
+// FileOutbox - выгрузка кодов выдачи в файл: по одному JSON объекту на строку.
+// Файл доступен только владельцу, так как содержит коды в открытом виде
+type FileOutbox struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileOutbox - создает выгрузку в файл path. Файл создается при первой записи
+func NewFileOutbox(path string) *FileOutbox {
+	return &FileOutbox{path: path}
+}
+
+// Send - дописывает коды выдачи в конец файла и сбрасывает их на диск
+func (o *FileOutbox) Send(notices ...model.PickupNotice) error {
+	if len(notices) == 0 {
+		return nil
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	file, err := os.OpenFile(o.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteOutbox, err)
+	}
+	defer file.Close()
+
+	buf := bufio.NewWriter(file)
+	encoder := json.NewEncoder(buf)
+	for _, notice := range notices {
+		if err = encoder.Encode(notice); err != nil {
+			return fmt.Errorf("%w: %w", ErrWriteOutbox, err)
+		}
+	}
+
+	if err = buf.Flush(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteOutbox, err)
+	}
+	if err = file.Sync(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteOutbox, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package packaging
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+// Package - тип упаковки, доступный при приеме заказов
+type Package struct {
+	Name model.PackageType `json:"name"`
+	Cost float64           `json:"cost"`
+	// MaxWeight - максимальный вес заказа в кг, 0 - без ограничения
+	MaxWeight float64 `json:"max_weight,omitempty"`
+	// MaxDimensions - максимальные габариты заказа в см, nil - без ограничения
+	MaxDimensions *model.Dimensions `json:"max_dimensions,omitempty"`
+	// CostPerKg - плата за кг оплачиваемого веса
+	CostPerKg float64 `json:"cost_per_kg,omitempty"`
+	// VolumetricDivisor - делитель объемного веса в см³/кг, 0 - оплачивается фактический вес
+	VolumetricDivisor float64 `json:"volumetric_divisor,omitempty"`
+}
+
+// VolumetricWeight - объемный вес заказа с габаритами dimensions в кг, 0 - если упаковка его не учитывает
+func (p Package) VolumetricWeight(dimensions model.Dimensions) float64 {
+	if p.VolumetricDivisor <= 0 {
+		return 0
+	}
+
+	return dimensions.Volume() / p.VolumetricDivisor
+}
+
+// Wrapper - дополнительная обертка поверх упаковки
+type Wrapper struct {
+	Name model.WrapperType `json:"name"`
+	Cost float64           `json:"cost"`
+	// MaxWeightAdjustment - изменение максимального веса упаковки в кг
+	MaxWeightAdjustment float64 `json:"max_weight_adjustment,omitempty"`
+}
+
+// Registry - реестр типов упаковки и оберток. Заполняется из политики при запуске,
+// новые типы можно зарегистрировать без изменения кода сервиса
+type Registry struct {
+	mu       sync.RWMutex
+	packages map[model.PackageType]Package
+	wrappers map[model.WrapperType]Wrapper
+}
+
+// NewRegistry - создает пустой реестр упаковки
+func NewRegistry() *Registry {
+	return &Registry{
+		packages: make(map[model.PackageType]Package),
+		wrappers: make(map[model.WrapperType]Wrapper),
+	}
+}
+
+// FromPolicy - создает реестр с типами упаковки и обертками из политики.
+// Значения политики должны быть проверены policy.Validate
+func FromPolicy(p *policy.Policy) *Registry {
+	r := NewRegistry()
+	for name, spec := range p.Packages {
+		r.RegisterPackage(Package{
+			Name:              name,
+			Cost:              spec.Cost,
+			MaxWeight:         spec.MaxWeight,
+			MaxDimensions:     spec.MaxDimensions,
+			CostPerKg:         spec.CostPerKg,
+			VolumetricDivisor: spec.VolumetricDivisor,
+		})
+	}
+	for name, spec := range p.Wrappers {
+		r.RegisterWrapper(Wrapper{Name: name, Cost: spec.Cost, MaxWeightAdjustment: spec.MaxWeightAdjustment})
+	}
+
+	return r
+}
+
+// RegisterPackage - регистрирует тип упаковки, заменяя ранее зарегистрированный с тем же названием
+func (r *Registry) RegisterPackage(pkg Package) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.packages[pkg.Name] = pkg
+}
+
+// RegisterWrapper - регистрирует обертку, заменяя ранее зарегистрированную с тем же названием
+func (r *Registry) RegisterWrapper(wrapper Wrapper) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.wrappers[wrapper.Name] = wrapper
+}
+
+// Package - находит тип упаковки по названию
+func (r *Registry) Package(name model.PackageType) (Package, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pkg, ok := r.packages[name]
+	return pkg, ok
+}
+
+// Wrapper - находит обертку по названию
+func (r *Registry) Wrapper(name model.WrapperType) (Wrapper, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wrapper, ok := r.wrappers[name]
+	return wrapper, ok
+}
+
+// Packages - возвращает зарегистрированные типы упаковки по алфавиту
+func (r *Registry) Packages() []Package {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]Package, 0, len(r.packages))
+	for _, pkg := range r.packages {
+		list = append(list, pkg)
+	}
+	slices.SortFunc(list, func(a, b Package) int {
+		return strings.Compare(string(a.Name), string(b.Name))
+	})
+
+	return list
+}
+
+// Wrappers - возвращает зарегистрированные обертки по алфавиту
+func (r *Registry) Wrappers() []Wrapper {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]Wrapper, 0, len(r.wrappers))
+	for _, wrapper := range r.wrappers {
+		list = append(list, wrapper)
+	}
+	slices.SortFunc(list, func(a, b Wrapper) int {
+		return strings.Compare(string(a.Name), string(b.Name))
+	})
+
+	return list
+}
@@ -0,0 +1,95 @@
+package packaging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+func loadPolicy(t *testing.T, data string) *policy.Policy {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := policy.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func TestFromPolicyMergesOverrides(t *testing.T) {
+	r := FromPolicy(loadPolicy(t, `{"packages": {"bag": {"cost": 7}}, "wrappers": {"film": {"cost": 2}}}`))
+
+	// у типа по умолчанию меняется только указанное поле, ограничение веса сохраняется
+	bag, ok := r.Package(model.PackageBag)
+	if !ok {
+		t.Fatal("bag не зарегистрирован")
+	}
+	defaults := policy.Default().Packages[model.PackageBag]
+	if bag.Cost != 7 || bag.MaxWeight != defaults.MaxWeight {
+		t.Errorf("bag = %+v, ожидалась стоимость 7 и максимальный вес %v", bag, defaults.MaxWeight)
+	}
+
+	if film, _ := r.Wrapper(model.WrapperFilm); film.Cost != 2 {
+		t.Errorf("обертка film = %+v, ожидалась стоимость 2", film)
+	}
+	if box, _ := r.Package(model.PackageBox); box.Cost != policy.Default().Packages[model.PackageBox].Cost {
+		t.Errorf("box = %+v, не указанный в файле тип должен остаться по умолчанию", box)
+	}
+}
+
+func TestFromPolicyRegistersCustomTypes(t *testing.T) {
+	r := FromPolicy(loadPolicy(t, `{
+		"packages": {"envelope": {"cost": 2, "max_weight": 0.5, "max_dimensions": {"length": 35, "width": 25, "height": 2}}},
+		"wrappers": {"bubble": {"cost": 3}}
+	}`))
+
+	envelope, ok := r.Package("envelope")
+	if !ok {
+		t.Fatal("envelope не зарегистрирован")
+	}
+	if envelope.Cost != 2 || envelope.MaxWeight != 0.5 || envelope.MaxDimensions == nil || envelope.MaxDimensions.Height != 2 {
+		t.Errorf("envelope = %+v", envelope)
+	}
+	if _, ok = r.Wrapper("bubble"); !ok {
+		t.Error("bubble не зарегистрирована")
+	}
+
+	// типы по умолчанию остаются доступны, список отсортирован по названию
+	var names []model.PackageType
+	for _, pkg := range r.Packages() {
+		names = append(names, pkg.Name)
+	}
+	want := []model.PackageType{model.PackageBag, model.PackageBox, "envelope", model.PackageFilm}
+	if len(names) != len(want) {
+		t.Fatalf("типы упаковки %v, ожидалось %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("типы упаковки %v, ожидалось %v", names, want)
+		}
+	}
+}
+
+func TestRegistryLookup(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterPackage(Package{Name: "crate", Cost: 10})
+	r.RegisterPackage(Package{Name: "crate", Cost: 12})
+
+	if crate, ok := r.Package("crate"); !ok || crate.Cost != 12 {
+		t.Errorf("crate = %+v, %v, ожидалась замена ранее зарегистрированного типа", crate, ok)
+	}
+	if _, ok := r.Package("pallet"); ok {
+		t.Error("найден незарегистрированный тип упаковки")
+	}
+	if _, ok := r.Wrapper("rope"); ok {
+		t.Error("найдена незарегистрированная обертка")
+	}
+}
@@ -0,0 +1,76 @@
+package policy
+
+import (
+	"fmt"
+	"slices"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+// CellGroup - группа одинаковых ячеек хранения на стеллаже
+type CellGroup struct {
+	// Shelf - обозначение стеллажа, из него и номера складывается ID ячейки: "A-01"
+	Shelf string `json:"shelf"`
+	Count int    `json:"count"`
+	// MaxWeight - суммарный вес заказов в одной ячейке, кг
+	MaxWeight float64 `json:"max_weight"`
+	// PackageTypes - типы упаковки, которые можно класть в ячейку.
+	// Пустой список - ячейка для заказов любой упаковки, в том числе без нее
+	PackageTypes []model.PackageType `json:"package_types,omitempty"`
+}
+
+// Cell - ячейка хранения
+type Cell struct {
+	ID           string
+	Shelf        string
+	MaxWeight    float64
+	PackageTypes []model.PackageType
+}
+
+// Accepts - проверяет, можно ли положить в ячейку заказ с упаковкой packageType (nil - без упаковки)
+func (c Cell) Accepts(packageType *model.PackageType) bool {
+	if len(c.PackageTypes) == 0 {
+		return true
+	}
+
+	return packageType != nil && slices.Contains(c.PackageTypes, *packageType)
+}
+
+// CellLayout - возвращает все ячейки в порядке стеллажей из политики
+func (p *Policy) CellLayout() []Cell {
+	var cells []Cell
+	for _, group := range p.Cells {
+		for i := 1; i <= group.Count; i++ {
+			cells = append(cells, Cell{
+				ID:           fmt.Sprintf("%s-%02d", group.Shelf, i),
+				Shelf:        group.Shelf,
+				MaxWeight:    group.MaxWeight,
+				PackageTypes: group.PackageTypes,
+			})
+		}
+	}
+
+	return cells
+}
+
+// validateCells - проверяет схему ячеек
+func (p *Policy) validateCells() error {
+	shelves := make(map[string]bool, len(p.Cells))
+	for _, group := range p.Cells {
+		if group.Shelf == "" || shelves[group.Shelf] {
+			return fmt.Errorf("%w: обозначение стеллажа %q пустое или повторяется", ErrInvalidPolicy, group.Shelf)
+		}
+		shelves[group.Shelf] = true
+
+		if group.Count <= 0 || group.MaxWeight <= 0 {
+			return fmt.Errorf("%w: число ячеек и их вместимость на стеллаже %q должны быть больше 0", ErrInvalidPolicy, group.Shelf)
+		}
+		for _, packageType := range group.PackageTypes {
+			if !p.hasPackage(packageType) {
+				return fmt.Errorf("%w: неизвестный тип упаковки %q на стеллаже %q", ErrInvalidPolicy, packageType, group.Shelf)
+			}
+		}
+	}
+
+	return nil
+}
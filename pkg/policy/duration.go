@@ -0,0 +1,33 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration - длительность, которая в JSON записывается строкой вида "48h"
+type Duration struct {
+	time.Duration
+}
+
+// MarshalJSON - записывает длительность строкой
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON - читает длительность из строки вида "48h30m"
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("длительность должна быть строкой, например \"48h\": %w", err)
+	}
+
+	dur, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = dur
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package policy
+
+import (
+	"fmt"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+// Point - возвращает пункт выдачи с указанным ID
+func (p *Policy) Point(id string) (model.PickupPoint, bool) {
+	for _, point := range p.Points {
+		if point.ID == id {
+			return point, true
+		}
+	}
+
+	return model.PickupPoint{}, false
+}
+
+// validatePoints - проверяет, что ID пунктов выдачи заданы и не повторяются
+func (p *Policy) validatePoints() error {
+	seen := make(map[string]bool, len(p.Points))
+	for _, point := range p.Points {
+		if point.ID == "" {
+			return fmt.Errorf("%w: у пункта выдачи не указан id", ErrInvalidPolicy)
+		}
+		if seen[point.ID] {
+			return fmt.Errorf("%w: пункт выдачи %q указан дважды", ErrInvalidPolicy, point.ID)
+		}
+		seen[point.ID] = true
+	}
+
+	return nil
+}
+
+// validateTransfer - проверяет правила перемещения заказов
+func (p *Policy) validateTransfer() error {
+	switch p.Transfer.Deadline {
+	case TransferDeadlinePreserve:
+		return nil
+	case TransferDeadlineReset:
+		if p.Transfer.StoragePeriod.Duration <= 0 {
+			return fmt.Errorf("%w: transfer.storage_period должен быть больше 0", ErrInvalidPolicy)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: transfer.deadline должен быть preserve или reset, получено %q", ErrInvalidPolicy, p.Transfer.Deadline)
+	}
+}
@@ -0,0 +1,361 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+var (
+	ErrOpenPolicy    = errors.New("ошибка при открытии файла политики")
+	ErrParsePolicy   = errors.New("ошибка при разборе файла политики")
+	ErrInvalidPolicy = errors.New("недопустимые значения в файле политики")
+)
+
+// packagingName - допустимое название типа упаковки или обертки
+var packagingName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// EnvPath - переменная окружения с путем к файлу политики
+const EnvPath = "PVZ_POLICY"
+
+// Policy - бизнес-правила пунктов выдачи: сроки, тарифы, ограничения упаковки, схема ячеек хранения
+// и список пунктов. Ячейки и вместимость одинаковы для всех пунктов и действуют в каждом из них отдельно
+type Policy struct {
+	// ReturnWindow - срок, в течение которого клиент может вернуть выданный заказ
+	ReturnWindow Duration `json:"return_window"`
+	// Packages и Wrappers - типы упаковки и обертки, которые регистрируются в реестре упаковки при запуске.
+	// Типы из файла добавляются к типам по умолчанию, а у известных типов заменяют только указанные поля
+	Packages map[model.PackageType]PackageSpec `json:"packages"`
+	Wrappers map[model.WrapperType]WrapperSpec `json:"wrappers"`
+	// Cells - стеллажи с ячейками хранения. Если не заданы, ячейки заказам не назначаются
+	Cells []CellGroup `json:"cells,omitempty"`
+	// Capacity - вместимость ПВЗ. Нулевые значения - без ограничения
+	Capacity CapacitySpec `json:"capacity"`
+	// StorageFee - платное хранение после бесплатного срока
+	StorageFee StorageFeeSpec `json:"storage_fee"`
+	// PickupCode - ограничение попыток ввода кода выдачи
+	PickupCode PickupCodeSpec `json:"pickup_code"`
+	// Extension - ограничения и плата за продление срока хранения
+	Extension ExtensionSpec `json:"extension"`
+	// Transfer - правила перемещения заказов между пунктами выдачи
+	Transfer TransferSpec `json:"transfer"`
+	// Pricing - правила расчета стоимости заказа: надбавки и скидки
+	Pricing PricingSpec `json:"pricing"`
+	// Points - пункты выдачи, обслуживаемые приложением. Если не заданы, все заказы относятся к одному ПВЗ
+	Points []model.PickupPoint `json:"points,omitempty"`
+
+	// Source - путь к файлу, из которого загружена политика; пустой для политики по умолчанию
+	Source string `json:"-"`
+}
+
+// PackageSpec - тариф и ограничения типа упаковки
+type PackageSpec struct {
+	Cost float64 `json:"cost"`
+	// MaxWeight - максимальный вес заказа в кг, 0 - без ограничения
+	MaxWeight float64 `json:"max_weight,omitempty"`
+	// MaxDimensions - максимальные габариты заказа в см, nil - без ограничения
+	MaxDimensions *model.Dimensions `json:"max_dimensions,omitempty"`
+	// CostPerKg - плата за кг оплачиваемого веса, добавляется к Cost
+	CostPerKg float64 `json:"cost_per_kg,omitempty"`
+	// VolumetricDivisor - делитель объемного веса в см³/кг. Если задан, для упаковки обязательны габариты заказа,
+	// а оплачиваемый вес - больший из фактического и объемного (объем / делитель)
+	VolumetricDivisor float64 `json:"volumetric_divisor,omitempty"`
+}
+
+// CapacitySpec - ограничения на заказы, одновременно хранящиеся в ПВЗ. 0 - без ограничения
+type CapacitySpec struct {
+	MaxOrders int `json:"max_orders,omitempty"`
+	// MaxWeight - суммарный вес хранящихся заказов, кг
+	MaxWeight float64 `json:"max_weight,omitempty"`
+	// MaxByPackage - число хранящихся заказов с данным типом упаковки
+	MaxByPackage map[model.PackageType]int `json:"max_by_package,omitempty"`
+}
+
+// StorageFeeSpec - платное хранение: по истечении бесплатного срока с момента приема за каждые
+// начатые сутки хранения начисляется плата, которая добавляется к стоимости заказа при выдаче
+type StorageFeeSpec struct {
+	FreePeriod Duration `json:"free_period"`
+	// DailyFee - плата за сутки, 0 - хранение бесплатно
+	DailyFee float64 `json:"daily_fee"`
+}
+
+// PickupCodeSpec - защита кода выдачи от перебора: после MaxAttempts неверных попыток подряд
+// выдача заказа блокируется на Lockout
+type PickupCodeSpec struct {
+	MaxAttempts int      `json:"max_attempts"`
+	Lockout     Duration `json:"lockout"`
+}
+
+// ExtensionSpec - ограничения продления срока хранения заказа. 0 - без ограничения
+type ExtensionSpec struct {
+	// MaxCount - сколько раз можно продлить срок хранения одного заказа
+	MaxCount int `json:"max_count"`
+	// MaxTotal - на сколько всего можно продлить срок хранения одного заказа
+	MaxTotal Duration `json:"max_total"`
+	// Fee - плата за одно продление, добавляется к стоимости заказа
+	Fee float64 `json:"fee"`
+}
+
+// TransferDeadline - срок хранения заказа после перемещения в другой пункт
+type TransferDeadline string
+
+const (
+	// TransferDeadlinePreserve - срок хранения не меняется
+	TransferDeadlinePreserve TransferDeadline = "preserve"
+	// TransferDeadlineReset - срок хранения отсчитывается заново с момента прибытия
+	TransferDeadlineReset TransferDeadline = "reset"
+)
+
+// TransferSpec - правила перемещения заказов между пунктами выдачи
+type TransferSpec struct {
+	Deadline TransferDeadline `json:"deadline"`
+	// StoragePeriod - срок хранения в пункте назначения при deadline = reset
+	StoragePeriod Duration `json:"storage_period"`
+}
+
+// WrapperSpec - тариф дополнительной обертки
+type WrapperSpec struct {
+	Cost float64 `json:"cost"`
+	// MaxWeightAdjustment - на сколько кг обертка увеличивает (или при отрицательном значении уменьшает)
+	// максимальный вес упаковки. Не действует для упаковки без ограничения веса
+	MaxWeightAdjustment float64 `json:"max_weight_adjustment,omitempty"`
+}
+
+// Default - возвращает политику по умолчанию
+func Default() *Policy {
+	return &Policy{
+		ReturnWindow: Duration{48 * time.Hour},
+		Packages: map[model.PackageType]PackageSpec{
+			model.PackageBag:  {Cost: 5, MaxWeight: 10},
+			model.PackageBox:  {Cost: 20, MaxWeight: 30},
+			model.PackageFilm: {Cost: 1},
+		},
+		Wrappers: map[model.WrapperType]WrapperSpec{
+			model.WrapperFilm: {Cost: 1},
+		},
+		StorageFee: StorageFeeSpec{
+			FreePeriod: Duration{72 * time.Hour},
+		},
+		PickupCode: PickupCodeSpec{
+			MaxAttempts: 5,
+			Lockout:     Duration{15 * time.Minute},
+		},
+		Extension: ExtensionSpec{
+			MaxCount: 2,
+			MaxTotal: Duration{7 * 24 * time.Hour},
+		},
+		Transfer: TransferSpec{
+			Deadline:      TransferDeadlinePreserve,
+			StoragePeriod: Duration{48 * time.Hour},
+		},
+		Pricing: PricingSpec{
+			Rules: defaultPricingRules(),
+		},
+	}
+}
+
+// Load - загружает политику из JSON файла. Не указанные в файле разделы берутся из политики по умолчанию
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenPolicy, err)
+	}
+
+	p := Default()
+	if err = decodeStrict(data, p); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrParsePolicy, path, err)
+	}
+	if err = mergePackaging(p, data); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrParsePolicy, path, err)
+	}
+
+	if err = p.Validate(); err != nil {
+		return nil, err
+	}
+	p.Source = path
+
+	return p, nil
+}
+
+// packagingOverrides - типы упаковки и обертки из файла политики в исходном виде
+type packagingOverrides struct {
+	Packages map[model.PackageType]json.RawMessage `json:"packages"`
+	Wrappers map[model.WrapperType]json.RawMessage `json:"wrappers"`
+}
+
+// mergePackaging - накладывает поля типов упаковки и оберток из файла на значения по умолчанию.
+// encoding/json заменяет элемент карты целиком, и без этого поля, не указанные в файле, обнулялись бы
+func mergePackaging(p *Policy, data []byte) error {
+	var overrides packagingOverrides
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return err
+	}
+
+	defaults := Default()
+	if err := mergeSpecs(p.Packages, defaults.Packages, overrides.Packages); err != nil {
+		return err
+	}
+
+	return mergeSpecs(p.Wrappers, defaults.Wrappers, overrides.Wrappers)
+}
+
+// mergeSpecs - записывает в dst значения из raw, разобранные поверх значений по умолчанию из defaults
+func mergeSpecs[K comparable, V any](dst, defaults map[K]V, raw map[K]json.RawMessage) error {
+	for name, data := range raw {
+		spec := defaults[name]
+		if err := decodeStrict(data, &spec); err != nil {
+			return fmt.Errorf("%v: %w", name, err)
+		}
+		dst[name] = spec
+	}
+
+	return nil
+}
+
+// decodeStrict - разбирает JSON, не допуская неизвестных полей
+func decodeStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	return decoder.Decode(v)
+}
+
+// Resolve - загружает политику из path, а если он пуст - из файла в переменной окружения PVZ_POLICY.
+// Если не задано ни то, ни другое, возвращается политика по умолчанию
+func Resolve(path string) (*Policy, error) {
+	if path == "" {
+		path = os.Getenv(EnvPath)
+	}
+	if path == "" {
+		return Default(), nil
+	}
+
+	return Load(path)
+}
+
+// Validate - проверяет значения политики
+func (p *Policy) Validate() error {
+	if p.ReturnWindow.Duration <= 0 {
+		return fmt.Errorf("%w: return_window должен быть больше 0", ErrInvalidPolicy)
+	}
+
+	checks := []func() error{
+		p.validatePackages,
+		p.validateWrappers,
+		p.validateCapacity,
+		p.validateStorageFee,
+		p.validatePickupCode,
+		p.validateExtension,
+		p.validateTransfer,
+		p.validatePricing,
+		p.validatePoints,
+		p.validateCells,
+	}
+	for _, check := range checks {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validatePackages - проверяет тарифы и ограничения упаковки
+func (p *Policy) validatePackages() error {
+	for name, spec := range p.Packages {
+		if err := validatePackage(name, spec); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validatePackage - проверяет название, тариф и ограничения типа упаковки
+func validatePackage(name model.PackageType, spec PackageSpec) error {
+	if !packagingName.MatchString(string(name)) {
+		return fmt.Errorf("%w: название упаковки %q может содержать только строчные латинские буквы, цифры, - и _", ErrInvalidPolicy, name)
+	}
+	if name == model.PackageAuto {
+		return fmt.Errorf("%w: название упаковки %q зарезервировано для автоматического подбора", ErrInvalidPolicy, name)
+	}
+	if spec.Cost < 0 || spec.MaxWeight < 0 || spec.CostPerKg < 0 || spec.VolumetricDivisor < 0 {
+		return fmt.Errorf("%w: стоимость, вес и делитель объемного веса упаковки %q не могут быть отрицательными", ErrInvalidPolicy, name)
+	}
+	if spec.MaxDimensions != nil && !spec.MaxDimensions.Valid() {
+		return fmt.Errorf("%w: габариты упаковки %q должны быть больше 0", ErrInvalidPolicy, name)
+	}
+
+	return nil
+}
+
+// validateWrappers - проверяет тарифы оберток
+func (p *Policy) validateWrappers() error {
+	for name, spec := range p.Wrappers {
+		if !packagingName.MatchString(string(name)) {
+			return fmt.Errorf("%w: название обертки %q может содержать только строчные латинские буквы, цифры, - и _", ErrInvalidPolicy, name)
+		}
+		if spec.Cost < 0 {
+			return fmt.Errorf("%w: стоимость обертки %q не может быть отрицательной", ErrInvalidPolicy, name)
+		}
+	}
+
+	return nil
+}
+
+// validateCapacity - проверяет ограничения вместимости
+func (p *Policy) validateCapacity() error {
+	if p.Capacity.MaxOrders < 0 || p.Capacity.MaxWeight < 0 {
+		return fmt.Errorf("%w: вместимость ПВЗ не может быть отрицательной", ErrInvalidPolicy)
+	}
+
+	for name, limit := range p.Capacity.MaxByPackage {
+		if !p.hasPackage(name) {
+			return fmt.Errorf("%w: неизвестный тип упаковки %q в capacity", ErrInvalidPolicy, name)
+		}
+		if limit < 0 {
+			return fmt.Errorf("%w: вместимость для упаковки %q не может быть отрицательной", ErrInvalidPolicy, name)
+		}
+	}
+
+	return nil
+}
+
+// validateStorageFee - проверяет условия платного хранения
+func (p *Policy) validateStorageFee() error {
+	if p.StorageFee.FreePeriod.Duration < 0 || p.StorageFee.DailyFee < 0 {
+		return fmt.Errorf("%w: бесплатный срок и плата за хранение не могут быть отрицательными", ErrInvalidPolicy)
+	}
+
+	return nil
+}
+
+// validatePickupCode - проверяет ограничение попыток ввода кода выдачи
+func (p *Policy) validatePickupCode() error {
+	if p.PickupCode.MaxAttempts <= 0 || p.PickupCode.Lockout.Duration <= 0 {
+		return fmt.Errorf("%w: pickup_code.max_attempts и pickup_code.lockout должны быть больше 0", ErrInvalidPolicy)
+	}
+
+	return nil
+}
+
+// validateExtension - проверяет ограничения продления срока хранения
+func (p *Policy) validateExtension() error {
+	if p.Extension.MaxCount < 0 || p.Extension.MaxTotal.Duration < 0 || p.Extension.Fee < 0 {
+		return fmt.Errorf("%w: ограничения и плата за продление хранения не могут быть отрицательными", ErrInvalidPolicy)
+	}
+
+	return nil
+}
+
+// hasPackage - проверяет, задан ли в политике тип упаковки
+func (p *Policy) hasPackage(name model.PackageType) bool {
+	_, ok := p.Packages[name]
+	return ok
+}
@@ -0,0 +1,116 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+func writePolicy(t *testing.T, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		check   func(t *testing.T, p *Policy)
+		wantErr error
+	}{
+		{
+			name: "не указанные разделы берутся по умолчанию",
+			data: `{"return_window": "24h"}`,
+			check: func(t *testing.T, p *Policy) {
+				want := Default()
+				want.ReturnWindow = Duration{24 * time.Hour}
+				want.Source = p.Source
+				if !reflect.DeepEqual(p, want) {
+					t.Errorf("политика = %+v, ожидалось %+v", p, want)
+				}
+			},
+		},
+		{
+			name: "частичное переопределение упаковки сохраняет остальные поля",
+			data: `{"packages": {"bag": {"cost": 7}}}`,
+			check: func(t *testing.T, p *Policy) {
+				want := Default().Packages[model.PackageBag]
+				want.Cost = 7
+				if got := p.Packages[model.PackageBag]; !reflect.DeepEqual(got, want) {
+					t.Errorf("bag = %+v, ожидалось %+v", got, want)
+				}
+				if got, want := p.Packages[model.PackageBox], Default().Packages[model.PackageBox]; !reflect.DeepEqual(got, want) {
+					t.Errorf("box = %+v, ожидалось %+v", got, want)
+				}
+			},
+		},
+		{
+			name: "новые типы упаковки и оберток добавляются к типам по умолчанию",
+			data: `{"packages": {"envelope": {"cost": 2, "max_weight": 0.5}}, "wrappers": {"bubble": {"cost": 3}}}`,
+			check: func(t *testing.T, p *Policy) {
+				if got, want := p.Packages["envelope"], (PackageSpec{Cost: 2, MaxWeight: 0.5}); !reflect.DeepEqual(got, want) {
+					t.Errorf("envelope = %+v, ожидалось %+v", got, want)
+				}
+				if got := p.Wrappers["bubble"]; got.Cost != 3 {
+					t.Errorf("bubble = %+v", got)
+				}
+				if len(p.Packages) != len(Default().Packages)+1 || len(p.Wrappers) != len(Default().Wrappers)+1 {
+					t.Errorf("упаковок %d, оберток %d", len(p.Packages), len(p.Wrappers))
+				}
+			},
+		},
+		{
+			name:    "неизвестное поле упаковки",
+			data:    `{"packages": {"bag": {"price": 7}}}`,
+			wantErr: ErrParsePolicy,
+		},
+		{
+			name:    "неизвестный раздел",
+			data:    `{"return": "24h"}`,
+			wantErr: ErrParsePolicy,
+		},
+		{
+			name:    "недопустимое значение",
+			data:    `{"packages": {"bag": {"cost": -1}}}`,
+			wantErr: ErrInvalidPolicy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writePolicy(t, tt.data)
+
+			p, err := Load(path)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ошибка = %v, ожидалась %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Source != path {
+				t.Errorf("Source = %q, ожидалось %q", p.Source, path)
+			}
+			tt.check(t, p)
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, ErrOpenPolicy) {
+		t.Errorf("ошибка = %v, ожидалась %v", err, ErrOpenPolicy)
+	}
+}
@@ -0,0 +1,92 @@
+package policy
+
+import (
+	"fmt"
+	"slices"
+)
+
+// PricingRule - правило расчета стоимости заказа
+type PricingRule string
+
+const (
+	// PricingPackaging - стоимость упаковки и оберток
+	PricingPackaging PricingRule = "packaging"
+	// PricingWeightSurcharge - надбавка за каждый кг сверх порога
+	PricingWeightSurcharge PricingRule = "weight_surcharge"
+	// PricingFragile - плата за обработку хрупкого заказа
+	PricingFragile PricingRule = "fragile"
+	// PricingPromo - скидка в процентах по промокоду
+	PricingPromo PricingRule = "promo"
+	// PricingLoyalty - скидка в процентах постоянному клиенту
+	PricingLoyalty PricingRule = "loyalty"
+)
+
+// PricingSpec - правила расчета стоимости заказа при приеме. Правила применяются в порядке Rules,
+// процентные скидки считаются от суммы, набранной к этому моменту
+type PricingSpec struct {
+	Rules           []PricingRule       `json:"rules"`
+	WeightSurcharge WeightSurchargeSpec `json:"weight_surcharge"`
+	// FragileFee - плата за обработку хрупкого заказа
+	FragileFee float64 `json:"fragile_fee"`
+	// PromoCodes - промокоды и скидка по ним в процентах
+	PromoCodes map[string]float64 `json:"promo_codes,omitempty"`
+	Loyalty    LoyaltySpec        `json:"loyalty"`
+}
+
+// WeightSurchargeSpec - надбавка PerKg за каждый кг веса заказа сверх Threshold. PerKg = 0 - без надбавки
+type WeightSurchargeSpec struct {
+	Threshold float64 `json:"threshold"`
+	PerKg     float64 `json:"per_kg"`
+}
+
+// LoyaltySpec - скидка Percent процентов клиенту, которому выдано не меньше MinOrders заказов.
+// Percent = 0 - без скидки
+type LoyaltySpec struct {
+	MinOrders int     `json:"min_orders"`
+	Percent   float64 `json:"percent"`
+}
+
+// defaultPricingRules - порядок правил по умолчанию: сначала надбавки, затем скидки
+func defaultPricingRules() []PricingRule {
+	return []PricingRule{PricingPackaging, PricingWeightSurcharge, PricingFragile, PricingPromo, PricingLoyalty}
+}
+
+// validatePricing - проверяет правила расчета стоимости
+func (p *Policy) validatePricing() error {
+	if err := validatePricingRules(p.Pricing.Rules); err != nil {
+		return err
+	}
+
+	spec := p.Pricing
+	if spec.WeightSurcharge.Threshold < 0 || spec.WeightSurcharge.PerKg < 0 || spec.FragileFee < 0 || spec.Loyalty.MinOrders < 0 {
+		return fmt.Errorf("%w: надбавки и условия скидок в pricing не могут быть отрицательными", ErrInvalidPolicy)
+	}
+	if spec.Loyalty.Percent < 0 || spec.Loyalty.Percent > 100 {
+		return fmt.Errorf("%w: скидка постоянного клиента должна быть от 0 до 100%%", ErrInvalidPolicy)
+	}
+	for code, percent := range spec.PromoCodes {
+		if code == "" || percent <= 0 || percent > 100 {
+			return fmt.Errorf("%w: промокод %q должен быть непустым, скидка по нему - больше 0 и не больше 100%%", ErrInvalidPolicy, code)
+		}
+	}
+
+	return nil
+}
+
+// validatePricingRules - проверяет, что правила известны, не повторяются и среди них есть упаковка
+func validatePricingRules(rules []PricingRule) error {
+	known := defaultPricingRules()
+	for i, rule := range rules {
+		if !slices.Contains(known, rule) {
+			return fmt.Errorf("%w: неизвестное правило расчета стоимости %q", ErrInvalidPolicy, rule)
+		}
+		if slices.Contains(rules[:i], rule) {
+			return fmt.Errorf("%w: правило расчета стоимости %q указано несколько раз", ErrInvalidPolicy, rule)
+		}
+	}
+	if !slices.Contains(rules, PricingPackaging) {
+		return fmt.Errorf("%w: правило %q обязательно - стоимость упаковки входит в стоимость заказа", ErrInvalidPolicy, PricingPackaging)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+var (
+	ErrUnknownPromoCode = errors.New("неизвестный промокод")
+)
+
+// RuleBase - строка разбивки с объявленной стоимостью заказа, с нее начинается расчет
+const RuleBase = "base"
+
+// Input - параметры заказа, от которых зависит его стоимость
+type Input struct {
+	// Cost - объявленная стоимость заказа
+	Cost float64
+	// PackagingCost - стоимость упаковки с обертками
+	PackagingCost float64
+	Weight        float64
+	Fragile       bool
+	PromoCode     string
+	// CustomerOrders - число заказов, ранее выданных клиенту
+	CustomerOrders int
+}
+
+// Quote - рассчитанная стоимость заказа: строки разбивки в порядке правил и итог
+type Quote struct {
+	Lines []model.PriceLine
+	Total float64
+}
+
+// Add - добавляет в разбивку строку правила rule. Суммы округляются до копеек, нулевые строки не добавляются
+func (q *Quote) Add(rule, description string, amount float64) {
+	amount = Round(amount)
+	if amount == 0 {
+		return
+	}
+
+	q.Lines = append(q.Lines, model.PriceLine{Rule: rule, Description: description, Amount: amount})
+	q.Total = Round(q.Total + amount)
+}
+
+// Rule - правило расчета стоимости: добавляет в расчет надбавку или скидку
+type Rule interface {
+	Apply(in Input, q *Quote) error
+}
+
+// Pipeline - упорядоченный набор правил расчета стоимости
+type Pipeline struct {
+	rules []Rule
+	// promo - есть ли среди правил скидка по промокоду
+	promo bool
+}
+
+// New - создает набор правил из политики в заданном в ней порядке.
+// Значения политики должны быть проверены policy.Validate
+func New(spec policy.PricingSpec) *Pipeline {
+	p := &Pipeline{}
+	for _, name := range spec.Rules {
+		if rule := newRule(name, spec); rule != nil {
+			p.rules = append(p.rules, rule)
+		}
+		if name == policy.PricingPromo {
+			p.promo = true
+		}
+	}
+
+	return p
+}
+
+// NewPipeline - создает набор из переданных правил
+func NewPipeline(rules ...Rule) *Pipeline {
+	p := &Pipeline{rules: rules}
+	for _, rule := range rules {
+		if _, ok := rule.(promoRule); ok {
+			p.promo = true
+		}
+	}
+
+	return p
+}
+
+// Price - рассчитывает стоимость заказа: объявленная стоимость, затем правила по порядку
+func (p *Pipeline) Price(in Input) (Quote, error) {
+	if in.PromoCode != "" && !p.promo {
+		return Quote{}, fmt.Errorf("%w: %s", ErrUnknownPromoCode, in.PromoCode)
+	}
+
+	var q Quote
+	q.Add(RuleBase, "Стоимость заказа", in.Cost)
+	for _, rule := range p.rules {
+		if err := rule.Apply(in, &q); err != nil {
+			return Quote{}, err
+		}
+	}
+
+	return q, nil
+}
+
+// Round - округляет сумму до копеек
+func Round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func newRule(name policy.PricingRule, spec policy.PricingSpec) Rule {
+	switch name {
+	case policy.PricingPackaging:
+		return packagingRule{}
+	case policy.PricingWeightSurcharge:
+		return weightSurchargeRule(spec.WeightSurcharge)
+	case policy.PricingFragile:
+		return fragileRule{fee: spec.FragileFee}
+	case policy.PricingPromo:
+		return newPromoRule(spec.PromoCodes)
+	case policy.PricingLoyalty:
+		return loyaltyRule(spec.Loyalty)
+	default:
+		return nil
+	}
+}
+
+// packagingRule - стоимость упаковки и оберток
+type packagingRule struct{}
+
+func (packagingRule) Apply(in Input, q *Quote) error {
+	q.Add(string(policy.PricingPackaging), "Упаковка", in.PackagingCost)
+	return nil
+}
+
+// weightSurchargeRule - надбавка за каждый кг сверх порога
+type weightSurchargeRule policy.WeightSurchargeSpec
+
+func (r weightSurchargeRule) Apply(in Input, q *Quote) error {
+	if in.Weight > r.Threshold {
+		q.Add(string(policy.PricingWeightSurcharge),
+			fmt.Sprintf("Надбавка за вес сверх %g кг", r.Threshold), (in.Weight-r.Threshold)*r.PerKg)
+	}
+	return nil
+}
+
+// fragileRule - плата за обработку хрупкого заказа
+type fragileRule struct {
+	fee float64
+}
+
+func (r fragileRule) Apply(in Input, q *Quote) error {
+	if in.Fragile {
+		q.Add(string(policy.PricingFragile), "Обработка хрупкого заказа", r.fee)
+	}
+	return nil
+}
+
+// promoRule - скидка по промокоду от набранной суммы. Промокоды не зависят от регистра
+type promoRule struct {
+	codes map[string]float64
+}
+
+func newPromoRule(codes map[string]float64) promoRule {
+	r := promoRule{codes: make(map[string]float64, len(codes))}
+	for code, percent := range codes {
+		r.codes[strings.ToUpper(code)] = percent
+	}
+
+	return r
+}
+
+func (r promoRule) Apply(in Input, q *Quote) error {
+	if in.PromoCode == "" {
+		return nil
+	}
+
+	percent, ok := r.codes[strings.ToUpper(in.PromoCode)]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPromoCode, in.PromoCode)
+	}
+	q.Add(string(policy.PricingPromo), fmt.Sprintf("Промокод %s (-%g%%)", in.PromoCode, percent), -q.Total*percent/100)
+
+	return nil
+}
+
+// loyaltyRule - скидка постоянному клиенту от набранной суммы
+type loyaltyRule policy.LoyaltySpec
+
+func (r loyaltyRule) Apply(in Input, q *Quote) error {
+	if r.Percent > 0 && in.CustomerOrders >= r.MinOrders {
+		q.Add(string(policy.PricingLoyalty), fmt.Sprintf("Скидка постоянного клиента (-%g%%)", r.Percent), -q.Total*r.Percent/100)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package pricing
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+var testSpec = policy.PricingSpec{
+	WeightSurcharge: policy.WeightSurchargeSpec{Threshold: 5, PerKg: 10},
+	FragileFee:      30,
+	PromoCodes:      map[string]float64{"spring10": 10},
+	Loyalty:         policy.LoyaltySpec{MinOrders: 3, Percent: 5},
+}
+
+var allRules = []policy.PricingRule{
+	policy.PricingPackaging, policy.PricingWeightSurcharge, policy.PricingFragile, policy.PricingPromo, policy.PricingLoyalty,
+}
+
+// heavyFragile - заказ, к которому применяются все надбавки
+var heavyFragile = Input{Cost: 100, PackagingCost: 20, Weight: 7, Fragile: true}
+
+func withInput(in Input, change func(in *Input)) Input {
+	change(&in)
+	return in
+}
+
+func TestPipelinePrice(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     []policy.PricingRule
+		in        Input
+		wantRules []string
+		wantTotal float64
+		wantErr   error
+	}{
+		{
+			name:      "надбавки, затем промокод от набранной суммы",
+			rules:     allRules,
+			in:        withInput(heavyFragile, func(in *Input) { in.PromoCode = "SPRING10" }),
+			wantRules: []string{RuleBase, "packaging", "weight_surcharge", "fragile", "promo"},
+			wantTotal: 153,
+		},
+		{
+			name: "промокод до надбавок скидывает только с объявленной стоимости",
+			rules: []policy.PricingRule{
+				policy.PricingPromo, policy.PricingPackaging, policy.PricingWeightSurcharge, policy.PricingFragile,
+			},
+			in:        withInput(heavyFragile, func(in *Input) { in.PromoCode = "SPRING10" }),
+			wantRules: []string{RuleBase, "promo", "packaging", "weight_surcharge", "fragile"},
+			wantTotal: 160,
+		},
+		{
+			name:      "скидка постоянного клиента после промокода",
+			rules:     allRules,
+			in:        withInput(heavyFragile, func(in *Input) { in.PromoCode = "SPRING10"; in.CustomerOrders = 3 }),
+			wantRules: []string{RuleBase, "packaging", "weight_surcharge", "fragile", "promo", "loyalty"},
+			wantTotal: 145.35,
+		},
+		{
+			name:      "клиент без нужного числа заказов",
+			rules:     allRules,
+			in:        withInput(heavyFragile, func(in *Input) { in.CustomerOrders = 2 }),
+			wantRules: []string{RuleBase, "packaging", "weight_surcharge", "fragile"},
+			wantTotal: 170,
+		},
+		{
+			name:      "промокод без учета регистра, скидка до копеек",
+			rules:     allRules,
+			in:        Input{Cost: 33.33, PromoCode: "Spring10"},
+			wantRules: []string{RuleBase, "promo"},
+			wantTotal: 30,
+		},
+		{
+			name:      "нулевые надбавки не попадают в разбивку",
+			rules:     allRules,
+			in:        Input{Cost: 100, Weight: 5},
+			wantRules: []string{RuleBase},
+			wantTotal: 100,
+		},
+		{
+			name:    "неизвестный промокод",
+			rules:   allRules,
+			in:      Input{Cost: 100, PromoCode: "WINTER"},
+			wantErr: ErrUnknownPromoCode,
+		},
+		{
+			name:    "промокод без правила promo",
+			rules:   []policy.PricingRule{policy.PricingPackaging},
+			in:      Input{Cost: 100, PromoCode: "SPRING10"},
+			wantErr: ErrUnknownPromoCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := testSpec
+			spec.Rules = tt.rules
+
+			q, err := New(spec).Price(tt.in)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ошибка = %v, ожидалась %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rules := make([]string, 0, len(q.Lines))
+			sum := 0.0
+			for _, line := range q.Lines {
+				rules = append(rules, line.Rule)
+				sum = Round(sum + line.Amount)
+			}
+			if !slices.Equal(rules, tt.wantRules) {
+				t.Errorf("правила разбивки %v, ожидалось %v", rules, tt.wantRules)
+			}
+			if q.Total != tt.wantTotal || sum != q.Total {
+				t.Errorf("итог %v, сумма строк %v, ожидалось %v", q.Total, sum, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		in, want float64
+	}{
+		{in: 3.333, want: 3.33},
+		{in: 3.336, want: 3.34},
+		{in: -3.333, want: -3.33},
+		{in: 0.001, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := Round(tt.in); got != tt.want {
+			t.Errorf("Round(%v) = %v, ожидалось %v", tt.in, got, tt.want)
+		}
+	}
+}
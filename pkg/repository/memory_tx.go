@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"fmt"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+// memoryTx - транзакция InMemoryRepository. Заказы репозитория только читаются, а добавленные,
+// измененные и удаленные заказы хранятся отдельно до фиксации. Транзакцию использует одна горутина,
+// пока репозиторий заблокирован, поэтому собственная блокировка ей не нужна
+type memoryTx struct {
+	base map[int64]model.Order
+	// changes - измененные в транзакции заказы; nil - заказ удален
+	changes map[int64]*model.Order
+}
+
+func newMemoryTx(orders map[int64]model.Order) *memoryTx {
+	return &memoryTx{base: orders, changes: make(map[int64]*model.Order)}
+}
+
+// commit - применяет изменения к заказам репозитория и возвращает итоговую карту заказов
+func (t *memoryTx) commit() map[int64]model.Order {
+	for id, order := range t.changes {
+		if order == nil {
+			delete(t.base, id)
+			continue
+		}
+		t.base[id] = *order
+	}
+
+	return t.base
+}
+
+// find - возвращает заказ с учетом изменений транзакции
+func (t *memoryTx) find(id int64) (model.Order, bool) {
+	if order, ok := t.changes[id]; ok {
+		if order == nil {
+			return model.Order{}, false
+		}
+		return *order, true
+	}
+
+	order, ok := t.base[id]
+	return order, ok
+}
+
+func (t *memoryTx) put(order model.Order) {
+	t.changes[order.ID] = &order
+}
+
+// Add - добавляет заказ в транзакции
+func (t *memoryTx) Add(order model.Order) error {
+	if err := validateOrder(order); err != nil {
+		return err
+	}
+	if _, ok := t.find(order.ID); ok {
+		return fmt.Errorf("%w: %d", ErrOrderAlreadyExists, order.ID)
+	}
+	t.put(order)
+
+	return nil
+}
+
+// Update - обновляет заказ в транзакции
+func (t *memoryTx) Update(order model.Order) error {
+	if _, ok := t.find(order.ID); !ok {
+		return fmt.Errorf("%w: %d", ErrOrderNotFound, order.ID)
+	}
+	t.put(order)
+
+	return nil
+}
+
+// Delete - удаляет заказ в транзакции
+func (t *memoryTx) Delete(id int64) error {
+	if _, ok := t.find(id); !ok {
+		return fmt.Errorf("%w: %d", ErrOrderNotFound, id)
+	}
+	t.changes[id] = nil
+
+	return nil
+}
+
+// FindByID - находит заказ с учетом изменений транзакции
+func (t *memoryTx) FindByID(id int64) (model.Order, error) {
+	order, ok := t.find(id)
+	if !ok {
+		return model.Order{}, fmt.Errorf("%w: %d", ErrOrderNotFound, id)
+	}
+
+	return order, nil
+}
+
+// List - возвращает список всех заказов
+func (t *memoryTx) List() ([]model.Order, error) {
+	return t.filter(func(model.Order) bool { return true }), nil
+}
+
+// ListByCustomer - возвращает список заказов клиента
+func (t *memoryTx) ListByCustomer(customerID int64) ([]model.Order, error) {
+	return t.filter(func(order model.Order) bool {
+		return order.CustomerID == customerID
+	}), nil
+}
+
+// ListByState - возвращает список заказов в указанном состоянии
+func (t *memoryTx) ListByState(state model.OrderState) ([]model.Order, error) {
+	return t.filter(func(order model.Order) bool {
+		return order.State == state
+	}), nil
+}
+
+// SetAll - заменяет все заказы. Заказы репозитория заменяются только при фиксации
+func (t *memoryTx) SetAll(orders map[int64]model.Order) error {
+	t.base = copyOrders(orders)
+	t.changes = make(map[int64]*model.Order)
+
+	return nil
+}
+
+// GetAll - возвращает карту всех заказов с учетом изменений транзакции
+func (t *memoryTx) GetAll() (map[int64]model.Order, error) {
+	result := make(map[int64]model.Order, len(t.base)+len(t.changes))
+	for _, order := range t.filter(func(model.Order) bool { return true }) {
+		result[order.ID] = order
+	}
+
+	return result, nil
+}
+
+// WithTx - вложенная транзакция выполняется в рамках внешней, как и в SQLRepository
+func (t *memoryTx) WithTx(fn func(tx Repository) error) error {
+	return fn(t)
+}
+
+func (t *memoryTx) filter(match func(order model.Order) bool) []model.Order {
+	var list []model.Order
+	for id, order := range t.base {
+		if _, changed := t.changes[id]; !changed && match(order) {
+			list = append(list, order)
+		}
+	}
+	for _, order := range t.changes {
+		if order != nil && match(*order) {
+			list = append(list, *order)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"fmt"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+// PointRepository - представление общего репозитория, ограниченное заказами одного ПВЗ.
+// Новые заказы помечаются ID пункта, заказы других пунктов не находятся и не попадают в списки.
+// SetAll и GetAll работают со всем хранилищем: через них данные сохраняются и загружаются целиком
+type PointRepository struct {
+	Repository
+	point string
+	// unassigned - к пункту относятся и заказы без ID пункта, принятые до настройки нескольких пунктов
+	unassigned bool
+}
+
+// NewPointRepository - создает репозиторий заказов пункта point поверх общего репозитория.
+// unassigned - пункт по умолчанию, к которому относятся заказы без ID пункта
+func NewPointRepository(repo Repository, point string, unassigned bool) *PointRepository {
+	return &PointRepository{Repository: repo, point: point, unassigned: unassigned}
+}
+
+// Point - возвращает ID пункта выдачи
+func (r *PointRepository) Point() string {
+	return r.point
+}
+
+// Add - добавляет заказ, помечая его ID пункта
+func (r *PointRepository) Add(order model.Order) error {
+	order.PointID = r.point
+
+	return r.Repository.Add(order)
+}
+
+// Update - обновляет заказ пункта
+func (r *PointRepository) Update(order model.Order) error {
+	if _, err := r.FindByID(order.ID); err != nil {
+		return err
+	}
+
+	return r.Repository.Update(order)
+}
+
+// Delete - удаляет заказ пункта по ID
+func (r *PointRepository) Delete(id int64) error {
+	if _, err := r.FindByID(id); err != nil {
+		return err
+	}
+
+	return r.Repository.Delete(id)
+}
+
+// FindByID - находит заказ пункта по ID
+func (r *PointRepository) FindByID(id int64) (model.Order, error) {
+	order, err := r.Repository.FindByID(id)
+	if err != nil {
+		return model.Order{}, err
+	}
+	if !r.owns(order) {
+		return model.Order{}, fmt.Errorf("%w: %d", ErrOrderNotFound, id)
+	}
+
+	return order, nil
+}
+
+// List - возвращает список заказов пункта
+func (r *PointRepository) List() ([]model.Order, error) {
+	return r.filter(r.Repository.List())
+}
+
+// ListByCustomer - возвращает список заказов клиента в пункте
+func (r *PointRepository) ListByCustomer(customerID int64) ([]model.Order, error) {
+	return r.filter(r.Repository.ListByCustomer(customerID))
+}
+
+// ListByState - возвращает список заказов пункта в указанном состоянии
+func (r *PointRepository) ListByState(state model.OrderState) ([]model.Order, error) {
+	return r.filter(r.Repository.ListByState(state))
+}
+
+// WithTx - выполняет fn в транзакции общего репозитория, ограниченной заказами пункта
+func (r *PointRepository) WithTx(fn func(tx Repository) error) error {
+	return r.Repository.WithTx(func(tx Repository) error {
+		return fn(NewPointRepository(tx, r.point, r.unassigned))
+	})
+}
+
+func (r *PointRepository) filter(orders []model.Order, err error) ([]model.Order, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	var list []model.Order
+	for _, order := range orders {
+		if r.owns(order) {
+			list = append(list, order)
+		}
+	}
+
+	return list, nil
+}
+
+func (r *PointRepository) owns(order model.Order) bool {
+	return order.PointID == r.point || r.unassigned && order.PointID == ""
+}
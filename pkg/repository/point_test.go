@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+func pointOrder(id, customerID int64, point string) model.Order {
+	order := testOrder(id, customerID, model.StateAccepted)
+	order.PointID = point
+
+	return order
+}
+
+// sortedIDs - возвращает ID заказов по возрастанию
+func sortedIDs(orders []model.Order) []int64 {
+	var ids []int64
+	for _, order := range orders {
+		ids = append(ids, order.ID)
+	}
+	slices.Sort(ids)
+
+	return ids
+}
+
+func TestPointRepository(t *testing.T) {
+	tests := []struct {
+		name       string
+		point      string
+		unassigned bool
+		wantIDs    []int64
+		// wantCustomer - заказы клиента 1 в пункте
+		wantCustomer []int64
+		// foreign - заказ, который пункт не видит
+		foreign int64
+	}{
+		{name: "пункт по умолчанию", point: "msk-1", unassigned: true, wantIDs: []int64{1, 3}, wantCustomer: []int64{1}, foreign: 2},
+		{name: "другой пункт", point: "msk-2", wantIDs: []int64{2}, wantCustomer: []int64{2}, foreign: 3},
+	}
+
+	for name, base := range testRepositories(t) {
+		// заказ 3 принят до настройки нескольких пунктов
+		addOrders(t, base, pointOrder(1, 1, "msk-1"), pointOrder(2, 1, "msk-2"), pointOrder(3, 2, ""))
+
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				r := NewPointRepository(base, tt.point, tt.unassigned)
+				ids := func(orders []model.Order, err error) []int64 {
+					t.Helper()
+					if err != nil {
+						t.Fatal(err)
+					}
+					return sortedIDs(orders)
+				}
+
+				if got := ids(r.List()); !slices.Equal(got, tt.wantIDs) {
+					t.Errorf("List = %v, ожидалось %v", got, tt.wantIDs)
+				}
+				if got := ids(r.ListByState(model.StateAccepted)); !slices.Equal(got, tt.wantIDs) {
+					t.Errorf("ListByState = %v, ожидалось %v", got, tt.wantIDs)
+				}
+				if got := ids(r.ListByCustomer(1)); !slices.Equal(got, tt.wantCustomer) {
+					t.Errorf("ListByCustomer = %v, ожидалось %v", got, tt.wantCustomer)
+				}
+
+				// заказ другого пункта не находится и не изменяется
+				if _, err := r.FindByID(tt.foreign); !errors.Is(err, ErrOrderNotFound) {
+					t.Errorf("FindByID: ошибка = %v, ожидалась %v", err, ErrOrderNotFound)
+				}
+				if err := r.Update(pointOrder(tt.foreign, 1, tt.point)); !errors.Is(err, ErrOrderNotFound) {
+					t.Errorf("Update: ошибка = %v, ожидалась %v", err, ErrOrderNotFound)
+				}
+				if err := r.Delete(tt.foreign); !errors.Is(err, ErrOrderNotFound) {
+					t.Errorf("Delete: ошибка = %v, ожидалась %v", err, ErrOrderNotFound)
+				}
+
+				// GetAll работает со всем хранилищем
+				all, err := r.GetAll()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(all) != 3 {
+					t.Errorf("GetAll вернул %d заказов, ожидалось 3", len(all))
+				}
+			})
+		}
+	}
+}
+
+func TestPointRepositoryAddInTx(t *testing.T) {
+	for name, base := range testRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			addOrders(t, base, pointOrder(1, 1, "msk-1"))
+			r := NewPointRepository(base, "msk-2", false)
+
+			// транзакция тоже ограничена заказами пункта, новые заказы помечаются пунктом
+			err := r.WithTx(func(tx Repository) error {
+				if _, err := tx.FindByID(1); !errors.Is(err, ErrOrderNotFound) {
+					t.Errorf("FindByID в транзакции: ошибка = %v, ожидалась %v", err, ErrOrderNotFound)
+				}
+				return tx.Add(pointOrder(2, 1, ""))
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			order, err := base.FindByID(2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order.PointID != "msk-2" {
+				t.Errorf("пункт нового заказа %q, ожидался msk-2", order.PointID)
+			}
+		})
+	}
+}
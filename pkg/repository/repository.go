@@ -3,6 +3,7 @@ package repository
 import (
 	"errors"
 	"fmt"
+	"sync"
 
 	"gitlab.ozon.dev/gojhw1/pkg/model"
 )
@@ -19,12 +20,19 @@ type Repository interface {
 	Update(order model.Order) error
 	Delete(id int64) error
 	FindByID(id int64) (model.Order, error)
-	List() []model.Order
-	SetAll(orders map[int64]model.Order)
-	GetAll() map[int64]model.Order
+	List() ([]model.Order, error)
+	ListByCustomer(customerID int64) ([]model.Order, error)
+	ListByState(state model.OrderState) ([]model.Order, error)
+	SetAll(orders map[int64]model.Order) error
+	GetAll() (map[int64]model.Order, error)
+	// WithTx - выполняет fn в транзакции: если fn вернула ошибку, ни одно изменение не применяется.
+	// Внутри fn нужно обращаться только к переданному tx
+	WithTx(fn func(tx Repository) error) error
 }
 
+// InMemoryRepository - репозиторий заказов в памяти, безопасный для конкурентного использования
 type InMemoryRepository struct {
+	mu     sync.RWMutex
 	orders map[int64]model.Order
 }
 
@@ -37,13 +45,12 @@ func NewInMemoryRepository() *InMemoryRepository {
 
 // Add - добавляет заказ в репозиторий
 func (r *InMemoryRepository) Add(order model.Order) error {
-	if order.ID <= 0 {
-		return fmt.Errorf("%w: %d", ErrInvalidOrderID, order.ID)
+	if err := validateOrder(order); err != nil {
+		return err
 	}
 
-	if order.CustomerID <= 0 {
-		return fmt.Errorf("%w: %d", ErrInvalidCustomerID, order.CustomerID)
-	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
 	if _, ok := r.orders[order.ID]; ok {
 		return fmt.Errorf("%w: %d", ErrOrderAlreadyExists, order.ID)
@@ -55,6 +62,9 @@ func (r *InMemoryRepository) Add(order model.Order) error {
 
 // Update - обновляет уже существующий заказ
 func (r *InMemoryRepository) Update(order model.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if _, ok := r.orders[order.ID]; !ok {
 		return fmt.Errorf("%w: %d", ErrOrderNotFound, order.ID)
 	}
@@ -65,6 +75,9 @@ func (r *InMemoryRepository) Update(order model.Order) error {
 
 // Delete - удаляет заказ по ID
 func (r *InMemoryRepository) Delete(id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if _, ok := r.orders[id]; !ok {
 		return fmt.Errorf("%w: %d", ErrOrderNotFound, id)
 	}
@@ -75,6 +88,9 @@ func (r *InMemoryRepository) Delete(id int64) error {
 
 // FindByID - находит заказ по ID
 func (r *InMemoryRepository) FindByID(id int64) (model.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	order, ok := r.orders[id]
 	if !ok {
 		return model.Order{}, fmt.Errorf("%w: %d", ErrOrderNotFound, id)
@@ -84,28 +100,90 @@ func (r *InMemoryRepository) FindByID(id int64) (model.Order, error) {
 }
 
 // List - возвращает список всех заказов
-func (r *InMemoryRepository) List() []model.Order {
-	list := make([]model.Order, 0, len(r.orders))
+func (r *InMemoryRepository) List() ([]model.Order, error) {
+	return r.filter(func(model.Order) bool { return true }), nil
+}
+
+// ListByCustomer - возвращает список заказов клиента
+func (r *InMemoryRepository) ListByCustomer(customerID int64) ([]model.Order, error) {
+	return r.filter(func(order model.Order) bool {
+		return order.CustomerID == customerID
+	}), nil
+}
+
+// ListByState - возвращает список заказов в указанном состоянии
+func (r *InMemoryRepository) ListByState(state model.OrderState) ([]model.Order, error) {
+	return r.filter(func(order model.Order) bool {
+		return order.State == state
+	}), nil
+}
+
+// SetAll - устанавливает все заказы в репозиторий
+func (r *InMemoryRepository) SetAll(orders map[int64]model.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.orders = copyOrders(orders)
+
+	return nil
+}
+
+// GetAll - возвращает карту всех заказов
+func (r *InMemoryRepository) GetAll() (map[int64]model.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return copyOrders(r.orders), nil
+}
+
+// WithTx - выполняет fn над транзакцией, которая копирует только измененные заказы, и применяет
+// изменения, только если fn завершилась без ошибки. Начало и фиксация транзакции не зависят от числа
+// заказов в репозитории. На время транзакции репозиторий заблокирован для остальных операций
+func (r *InMemoryRepository) WithTx(fn func(tx Repository) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := newMemoryTx(r.orders)
+	if err := fn(tx); err != nil {
+		return err
+	}
+	r.orders = tx.commit()
+
+	return nil
+}
+
+func (r *InMemoryRepository) filter(match func(order model.Order) bool) []model.Order {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var list []model.Order
 	for _, order := range r.orders {
-		list = append(list, order)
+		if match(order) {
+			list = append(list, order)
+		}
 	}
 
 	return list
 }
 
-// SetAll - устанавливает все заказы в репозиторий
-func (r *InMemoryRepository) SetAll(orders map[int64]model.Order) {
-	r.orders = make(map[int64]model.Order, len(orders))
-	for k, v := range orders {
-		r.orders[k] = v
+// validateOrder - проверяет идентификаторы нового заказа
+func validateOrder(order model.Order) error {
+	if order.ID <= 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidOrderID, order.ID)
+	}
+
+	if order.CustomerID <= 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidCustomerID, order.CustomerID)
 	}
+
+	return nil
 }
 
-// GetAll - возвращает карту всех заказов
-func (r *InMemoryRepository) GetAll() map[int64]model.Order {
-	result := make(map[int64]model.Order, len(r.orders))
-	for k, v := range r.orders {
+func copyOrders(orders map[int64]model.Order) map[int64]model.Order {
+	result := make(map[int64]model.Order, len(orders))
+	for k, v := range orders {
 		result[k] = v
 	}
+
 	return result
 }
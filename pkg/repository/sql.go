@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/storage"
+	_ "modernc.org/sqlite"
+)
+
+const sqlDriver = "sqlite"
+
+// Индексируемые поля вынесены в отдельные колонки, заказ целиком хранится в колонке data
+// (storage.OrderRecord), поэтому новые поля model.Order не требуют миграции схемы
+var sqlSchema = []string{
+	`CREATE TABLE IF NOT EXISTS orders (
+		id          INTEGER PRIMARY KEY,
+		customer_id INTEGER NOT NULL,
+		state       TEXT    NOT NULL,
+		deadline_at INTEGER NOT NULL,
+		updated_at  INTEGER NOT NULL,
+		data        TEXT    NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders (customer_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_orders_state ON orders (state)`,
+	`CREATE INDEX IF NOT EXISTS idx_orders_deadline_at ON orders (deadline_at)`,
+	`CREATE TABLE IF NOT EXISTS order_events (
+		id       INTEGER PRIMARY KEY AUTOINCREMENT,
+		order_id INTEGER NOT NULL,
+		at       INTEGER NOT NULL,
+		data     TEXT    NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_order_events_order_id ON order_events (order_id)`,
+}
+
+var sqlPragmas = []string{
+	`PRAGMA journal_mode = WAL`,
+	`PRAGMA synchronous = FULL`,
+	`PRAGMA busy_timeout = 5000`,
+}
+
+// SQLRepository - репозиторий заказов во встроенной базе SQLite
+type SQLRepository struct {
+	db   *sql.DB
+	conn sqlConn
+	tx   *sql.Tx
+}
+
+// sqlConn - общее подмножество методов *sql.DB и *sql.Tx
+type sqlConn interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// NewSQLRepository - открывает (или создает) базу SQLite по указанному пути и применяет схему
+func NewSQLRepository(path string) (*SQLRepository, error) {
+	db, err := sql.Open(sqlDriver, path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия базы данных: %w", err)
+	}
+	// SQLite допускает только одного писателя, поэтому все обращения идут через одно соединение
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range append(sqlPragmas, sqlSchema...) {
+		if _, err = db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("ошибка инициализации базы данных: %w", err)
+		}
+	}
+
+	return &SQLRepository{db: db, conn: db}, nil
+}
+
+// Close - закрывает соединение с базой данных
+func (r *SQLRepository) Close() error {
+	return r.db.Close()
+}
+
+// Add - добавляет заказ в репозиторий
+func (r *SQLRepository) Add(order model.Order) error {
+	if err := validateOrder(order); err != nil {
+		return err
+	}
+
+	res, err := insertOrder(r.conn, order)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, ErrOrderAlreadyExists, order.ID)
+}
+
+// Update - обновляет уже существующий заказ
+func (r *SQLRepository) Update(order model.Order) error {
+	data, err := storage.MarshalOrder(order)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.conn.Exec(`UPDATE orders SET customer_id = ?, state = ?, deadline_at = ?, updated_at = ?, data = ?
+		WHERE id = ?`,
+		order.CustomerID, order.State, unixNano(order.DeadlineAt), unixNano(order.UpdatedAt), string(data), order.ID)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, ErrOrderNotFound, order.ID)
+}
+
+// Delete - удаляет заказ по ID
+func (r *SQLRepository) Delete(id int64) error {
+	res, err := r.conn.Exec(`DELETE FROM orders WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, ErrOrderNotFound, id)
+}
+
+// FindByID - находит заказ по ID
+func (r *SQLRepository) FindByID(id int64) (model.Order, error) {
+	var data string
+	err := r.conn.QueryRow(`SELECT data FROM orders WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Order{}, fmt.Errorf("%w: %d", ErrOrderNotFound, id)
+	}
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	return storage.UnmarshalOrder([]byte(data))
+}
+
+// List - возвращает список всех заказов
+func (r *SQLRepository) List() ([]model.Order, error) {
+	return r.query(`SELECT data FROM orders ORDER BY id`)
+}
+
+// ListByCustomer - возвращает список заказов клиента
+func (r *SQLRepository) ListByCustomer(customerID int64) ([]model.Order, error) {
+	return r.query(`SELECT data FROM orders WHERE customer_id = ? ORDER BY id`, customerID)
+}
+
+// ListByState - возвращает список заказов в указанном состоянии
+func (r *SQLRepository) ListByState(state model.OrderState) ([]model.Order, error) {
+	return r.query(`SELECT data FROM orders WHERE state = ? ORDER BY id`, state)
+}
+
+// SetAll - заменяет все заказы в репозитории
+func (r *SQLRepository) SetAll(orders map[int64]model.Order) error {
+	return r.withTx(func(tx *SQLRepository) error {
+		if _, err := tx.conn.Exec(`DELETE FROM orders`); err != nil {
+			return err
+		}
+
+		for _, order := range orders {
+			if _, err := insertOrder(tx.conn, order); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetAll - возвращает карту всех заказов
+func (r *SQLRepository) GetAll() (map[int64]model.Order, error) {
+	list, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int64]model.Order, len(list))
+	for _, order := range list {
+		result[order.ID] = order
+	}
+
+	return result, nil
+}
+
+// WithTx - выполняет fn в транзакции базы данных.
+// Транзакция фиксируется, только если fn завершилась без ошибки
+func (r *SQLRepository) WithTx(fn func(tx Repository) error) error {
+	return r.withTx(func(tx *SQLRepository) error {
+		return fn(tx)
+	})
+}
+
+func (r *SQLRepository) withTx(fn func(tx *SQLRepository) error) error {
+	// Вложенная транзакция выполняется в рамках внешней
+	if r.tx != nil {
+		return fn(r)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = fn(&SQLRepository{db: r.db, conn: tx, tx: tx}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Append - дописывает события заказов в таблицу order_events.
+// Вместе с ListByOrder позволяет использовать базу как журнал событий (audit.Log)
+func (r *SQLRepository) Append(events ...model.OrderEvent) error {
+	return r.withTx(func(tx *SQLRepository) error {
+		for _, event := range events {
+			event.ID = 0
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+
+			if _, err = tx.conn.Exec(`INSERT INTO order_events (order_id, at, data) VALUES (?, ?, ?)`,
+				event.OrderID, unixNano(event.At), string(data)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListByOrder - возвращает события заказа в порядке записи
+func (r *SQLRepository) ListByOrder(orderID int64) ([]model.OrderEvent, error) {
+	rows, err := r.conn.Query(`SELECT id, data FROM order_events WHERE order_id = ? ORDER BY id`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []model.OrderEvent
+	for rows.Next() {
+		var (
+			id   int64
+			data string
+		)
+		if err = rows.Scan(&id, &data); err != nil {
+			return nil, err
+		}
+
+		var event model.OrderEvent
+		if err = json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, err
+		}
+		event.ID = id
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// insertOrder - вставляет заказ, если заказа с таким ID еще нет
+func insertOrder(exec sqlConn, order model.Order) (sql.Result, error) {
+	data, err := storage.MarshalOrder(order)
+	if err != nil {
+		return nil, err
+	}
+
+	return exec.Exec(`INSERT INTO orders (id, customer_id, state, deadline_at, updated_at, data)
+		VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (id) DO NOTHING`,
+		order.ID, order.CustomerID, order.State, unixNano(order.DeadlineAt), unixNano(order.UpdatedAt), string(data))
+}
+
+func (r *SQLRepository) query(query string, args ...any) ([]model.Order, error) {
+	rows, err := r.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []model.Order
+	for rows.Next() {
+		var data string
+		if err = rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		order, err := storage.UnmarshalOrder([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, order)
+	}
+
+	return list, rows.Err()
+}
+
+func checkAffected(res sql.Result, notAffectedErr error, id int64) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", notAffectedErr, id)
+	}
+
+	return nil
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano()
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+var testTime = time.Date(2030, 2, 20, 15, 4, 5, 0, time.UTC)
+
+func testOrder(id, customerID int64, state model.OrderState) model.Order {
+	return model.Order{
+		ID:         id,
+		CustomerID: customerID,
+		State:      state,
+		Weight:     1.5,
+		Cost:       100,
+		DeadlineAt: testTime.Add(48 * time.Hour),
+		UpdatedAt:  testTime,
+	}
+}
+
+func openTestSQLRepository(t *testing.T, path string) *SQLRepository {
+	t.Helper()
+
+	r, err := NewSQLRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+
+	return r
+}
+
+func newTestSQLRepository(t *testing.T) *SQLRepository {
+	t.Helper()
+
+	return openTestSQLRepository(t, filepath.Join(t.TempDir(), "storage.db"))
+}
+
+// addOrders - добавляет заказы в репозиторий
+func addOrders(t *testing.T, r Repository, orders ...model.Order) {
+	t.Helper()
+
+	for _, order := range orders {
+		if err := r.Add(order); err != nil {
+			t.Fatalf("Add(%d): %v", order.ID, err)
+		}
+	}
+}
+
+func TestSQLRepositoryAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   model.Order
+		wantErr error
+	}{
+		{name: "новый заказ", order: testOrder(2, 1, model.StateAccepted)},
+		{name: "повторный ID", order: testOrder(1, 2, model.StateAccepted), wantErr: ErrOrderAlreadyExists},
+		{name: "недопустимый ID заказа", order: testOrder(0, 1, model.StateAccepted), wantErr: ErrInvalidOrderID},
+		{name: "недопустимый ID клиента", order: testOrder(3, 0, model.StateAccepted), wantErr: ErrInvalidCustomerID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestSQLRepository(t)
+			addOrders(t, r, testOrder(1, 1, model.StateAccepted))
+
+			err := r.Add(tt.order)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ошибка = %v, ожидалась %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := r.FindByID(tt.order.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.order) {
+				t.Errorf("FindByID = %+v, ожидалось %+v", got, tt.order)
+			}
+		})
+	}
+}
+
+func TestSQLRepositoryUpdateDelete(t *testing.T) {
+	tests := []struct {
+		name    string
+		do      func(r *SQLRepository) error
+		want    map[int64]model.Order
+		wantErr error
+	}{
+		{
+			name: "обновление",
+			do:   func(r *SQLRepository) error { return r.Update(testOrder(1, 1, model.StateDelivered)) },
+			want: map[int64]model.Order{1: testOrder(1, 1, model.StateDelivered)},
+		},
+		{
+			name:    "обновление отсутствующего заказа",
+			do:      func(r *SQLRepository) error { return r.Update(testOrder(2, 1, model.StateDelivered)) },
+			want:    map[int64]model.Order{1: testOrder(1, 1, model.StateAccepted)},
+			wantErr: ErrOrderNotFound,
+		},
+		{
+			name: "удаление",
+			do:   func(r *SQLRepository) error { return r.Delete(1) },
+			want: map[int64]model.Order{},
+		},
+		{
+			name:    "удаление отсутствующего заказа",
+			do:      func(r *SQLRepository) error { return r.Delete(2) },
+			want:    map[int64]model.Order{1: testOrder(1, 1, model.StateAccepted)},
+			wantErr: ErrOrderNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestSQLRepository(t)
+			addOrders(t, r, testOrder(1, 1, model.StateAccepted))
+
+			if err := tt.do(r); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ошибка = %v, ожидалась %v", err, tt.wantErr)
+			}
+
+			got, err := r.GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetAll = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLRepositoryLists(t *testing.T) {
+	r := newTestSQLRepository(t)
+	addOrders(t, r,
+		testOrder(3, 1, model.StateAccepted),
+		testOrder(1, 1, model.StateDelivered),
+		testOrder(2, 2, model.StateAccepted),
+	)
+
+	tests := []struct {
+		name    string
+		list    func() ([]model.Order, error)
+		wantIDs []int64
+	}{
+		{name: "все заказы по возрастанию ID", list: r.List, wantIDs: []int64{1, 2, 3}},
+		{name: "заказы клиента", list: func() ([]model.Order, error) { return r.ListByCustomer(1) }, wantIDs: []int64{1, 3}},
+		{name: "заказы в состоянии", list: func() ([]model.Order, error) { return r.ListByState(model.StateAccepted) }, wantIDs: []int64{2, 3}},
+		{name: "нет заказов клиента", list: func() ([]model.Order, error) { return r.ListByCustomer(5) }, wantIDs: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders, err := tt.list()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ids []int64
+			for _, order := range orders {
+				ids = append(ids, order.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("ID заказов = %v, ожидалось %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestSQLRepositorySetAll(t *testing.T) {
+	r := newTestSQLRepository(t)
+	addOrders(t, r, testOrder(1, 1, model.StateAccepted), testOrder(2, 1, model.StateAccepted))
+
+	// SetAll удаляет заказы, которых нет в новой базе, и заново вставляет остальные
+	want := map[int64]model.Order{
+		2: testOrder(2, 1, model.StateDelivered),
+		3: testOrder(3, 2, model.StateAccepted),
+	}
+	if err := r.SetAll(want); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAll = %v, ожидалось %v", got, want)
+	}
+}
+
+func TestSQLRepositoryReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.db")
+	box := model.PackageBox
+	delivered := testTime.Add(time.Hour)
+
+	order := testOrder(1, 1, model.StateDelivered)
+	order.PackageType = &box
+	order.DeliveredAt = &delivered
+	// хеш кода выдачи не выводится пользователям, но сохраняется в базе
+	accepted := testOrder(2, 2, model.StateAccepted)
+	accepted.PickupCodeHash = "0011223344556677$deadbeef"
+	want := map[int64]model.Order{1: order, 2: accepted}
+
+	r := openTestSQLRepository(t, path)
+	if err := r.SetAll(want); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := openTestSQLRepository(t, path).GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("после повторного открытия GetAll = %v, ожидалось %v", got, want)
+	}
+}
+
+func TestSQLRepositoryEvents(t *testing.T) {
+	r := newTestSQLRepository(t)
+
+	events := []model.OrderEvent{
+		{ID: 10, OrderID: 1, At: testTime, Actor: "operator", ToState: model.StateAccepted, Command: "accept_order"},
+		{OrderID: 2, At: testTime, Actor: "operator", ToState: model.StateAccepted, Command: "accept_order"},
+		{OrderID: 1, At: testTime.Add(time.Hour), Actor: "operator", FromState: model.StateAccepted, ToState: model.StateDelivered, Command: "process_customer"},
+	}
+	if err := r.Append(events...); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.ListByOrder(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ID событий назначает база в порядке записи, переданные ID не используются
+	want := []model.OrderEvent{events[0], events[2]}
+	want[0].ID, want[1].ID = 1, 3
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListByOrder = %+v, ожидалось %+v", got, want)
+	}
+}
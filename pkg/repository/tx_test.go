@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+var errTxFailed = errors.New("сбой в транзакции")
+
+// testRepositories - репозитории, на которых проверяется общее поведение
+func testRepositories(t *testing.T) map[string]Repository {
+	t.Helper()
+
+	return map[string]Repository{
+		"в памяти": NewInMemoryRepository(),
+		"SQLite":   newTestSQLRepository(t),
+	}
+}
+
+// changeInTx - добавляет, изменяет и удаляет заказы внутри транзакции и проверяет,
+// что транзакция видит свои изменения
+func changeInTx(t *testing.T, tx Repository) {
+	t.Helper()
+
+	addOrders(t, tx, testOrder(3, 1, model.StateAccepted))
+	if err := tx.Update(testOrder(1, 1, model.StateDelivered)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tx.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := txResult(); !reflect.DeepEqual(got, want) {
+		t.Errorf("внутри транзакции GetAll = %v, ожидалось %v", got, want)
+	}
+}
+
+func txInitial() map[int64]model.Order {
+	return map[int64]model.Order{1: testOrder(1, 1, model.StateAccepted), 2: testOrder(2, 1, model.StateAccepted)}
+}
+
+func txResult() map[int64]model.Order {
+	return map[int64]model.Order{1: testOrder(1, 1, model.StateDelivered), 3: testOrder(3, 1, model.StateAccepted)}
+}
+
+func TestWithTx(t *testing.T) {
+	tests := []struct {
+		name    string
+		fnErr   error
+		want    map[int64]model.Order
+		wantErr error
+	}{
+		{name: "фиксация", want: txResult()},
+		{name: "откат при ошибке", fnErr: errTxFailed, want: txInitial(), wantErr: errTxFailed},
+	}
+
+	for _, tt := range tests {
+		for name, r := range testRepositories(t) {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				if err := r.SetAll(txInitial()); err != nil {
+					t.Fatal(err)
+				}
+
+				err := r.WithTx(func(tx Repository) error {
+					changeInTx(t, tx)
+					return tt.fnErr
+				})
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ошибка = %v, ожидалась %v", err, tt.wantErr)
+				}
+
+				got, err := r.GetAll()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("GetAll = %v, ожидалось %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestNestedTx(t *testing.T) {
+	for name, r := range testRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := r.SetAll(txInitial()); err != nil {
+				t.Fatal(err)
+			}
+
+			// SetAll во вложенной транзакции выполняется во внешней и откатывается вместе с ней
+			err := r.WithTx(func(tx Repository) error {
+				err := tx.WithTx(func(nested Repository) error {
+					return nested.SetAll(txResult())
+				})
+				if err != nil {
+					return err
+				}
+				if _, err = tx.FindByID(3); err != nil {
+					t.Errorf("внешняя транзакция не видит изменения вложенной: %v", err)
+				}
+				return errTxFailed
+			})
+			if !errors.Is(err, errTxFailed) {
+				t.Fatalf("ошибка = %v, ожидалась %v", err, errTxFailed)
+			}
+
+			got, err := r.GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, txInitial()) {
+				t.Errorf("GetAll = %v, ожидалось %v", got, txInitial())
+			}
+		})
+	}
+}
+
+func TestInMemoryTxSetAll(t *testing.T) {
+	r := NewInMemoryRepository()
+	if err := r.SetAll(txInitial()); err != nil {
+		t.Fatal(err)
+	}
+
+	// изменения после SetAll в транзакции применяются к новым заказам, а не к прежним
+	err := r.WithTx(func(tx Repository) error {
+		if err := tx.SetAll(map[int64]model.Order{1: testOrder(1, 1, model.StateAccepted)}); err != nil {
+			return err
+		}
+		if _, err := tx.FindByID(2); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("заказ 2 после SetAll: %v", err)
+		}
+		addOrders(t, tx, testOrder(2, 1, model.StateAccepted))
+		changeInTx(t, tx)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, txResult()) {
+		t.Errorf("GetAll = %v, ожидалось %v", got, txResult())
+	}
+}
+
+func TestInMemoryTxListsChanges(t *testing.T) {
+	r := NewInMemoryRepository()
+	if err := r.SetAll(txInitial()); err != nil {
+		t.Fatal(err)
+	}
+
+	err := r.WithTx(func(tx Repository) error {
+		changeInTx(t, tx)
+
+		delivered, err := tx.ListByState(model.StateDelivered)
+		if err != nil {
+			return err
+		}
+		if len(delivered) != 1 || delivered[0].ID != 1 {
+			t.Errorf("выданные заказы в транзакции = %v", delivered)
+		}
+		if err = tx.Add(testOrder(3, 1, model.StateAccepted)); !errors.Is(err, ErrOrderAlreadyExists) {
+			t.Errorf("повторное добавление: %v", err)
+		}
+		if err = tx.Update(testOrder(2, 1, model.StateDelivered)); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("изменение удаленного заказа: %v", err)
+		}
+		return errTxFailed
+	})
+	if !errors.Is(err, errTxFailed) {
+		t.Fatalf("ошибка = %v, ожидалась %v", err, errTxFailed)
+	}
+
+	// откат не затрагивает заказы репозитория, которые транзакция читала напрямую
+	got, err := r.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, txInitial()) {
+		t.Errorf("GetAll = %v, ожидалось %v", got, txInitial())
+	}
+}
+
+// BenchmarkInMemoryWithTx - стоимость транзакции с одним заказом не должна расти с числом заказов
+func BenchmarkInMemoryWithTx(b *testing.B) {
+	for _, size := range []int{1_000, 100_000} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			orders := make(map[int64]model.Order, size)
+			for id := int64(1); id <= int64(size); id++ {
+				orders[id] = testOrder(id, 1, model.StateAccepted)
+			}
+			r := NewInMemoryRepository()
+			if err := r.SetAll(orders); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				err := r.WithTx(func(tx Repository) error {
+					return tx.Update(testOrder(1, 1, model.StateAccepted))
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
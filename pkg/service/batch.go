@@ -0,0 +1,250 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+var (
+	ErrBatchRejected    = errors.New("пакет заказов отклонен: в файле есть ошибки, ни один заказ не принят")
+	ErrUnknownBatchMode = errors.New("неизвестный режим пакетного приема, допустимые: atomic, best-effort")
+	ErrDuplicateInBatch = errors.New("заказ повторяется в файле")
+)
+
+// BatchMode - режим пакетного приема заказов
+type BatchMode string
+
+const (
+	// BatchAtomic - заказы принимаются, только если корректны все записи файла
+	BatchAtomic BatchMode = "atomic"
+	// BatchBestEffort - принимаются все корректные записи, остальные отклоняются
+	BatchBestEffort BatchMode = "best-effort"
+)
+
+// AcceptStatus - итог обработки одной записи пакета
+type AcceptStatus string
+
+const (
+	AcceptStatusAccepted AcceptStatus = "accepted"
+	AcceptStatusRejected AcceptStatus = "rejected"
+	// AcceptStatusSkipped - запись корректна, но не принята, так как пакет отклонен целиком
+	AcceptStatusSkipped AcceptStatus = "skipped"
+)
+
+// AcceptResult - результат обработки одной записи пакета
+type AcceptResult struct {
+	Line    int          `json:"line"`
+	OrderID int64        `json:"order_id"`
+	Status  AcceptStatus `json:"status"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// BatchReport - отчет о пакетном приеме заказов
+type BatchReport struct {
+	Mode     BatchMode      `json:"mode"`
+	Total    int            `json:"total"`
+	Accepted int            `json:"accepted"`
+	Rejected int            `json:"rejected"`
+	Skipped  int            `json:"skipped"`
+	Results  []AcceptResult `json:"results,omitempty"`
+}
+
+// ParseBatchMode - разбирает название режима пакетного приема. Пустая строка означает BatchAtomic
+func ParseBatchMode(s string) (BatchMode, error) {
+	switch mode := BatchMode(s); mode {
+	case "":
+		return BatchAtomic, nil
+	case BatchAtomic, BatchBestEffort:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownBatchMode, s)
+	}
+}
+
+// AcceptOrdersFromFile - принимает заказы из файла в формате JSON, NDJSON или CSV.
+// Если формат не указан в opts, он определяется по расширению и содержимому файла
+func (s *OrderService) AcceptOrdersFromFile(filename string, mode BatchMode, opts ImportOptions) (*BatchReport, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenFile, err)
+	}
+	defer file.Close()
+
+	return s.acceptOrdersFrom(file, filename, mode, opts)
+}
+
+// AcceptOrdersFromReader - принимает заказы, прочитанные из r.
+// Сначала проверяются все записи, затем заказы принимаются в соответствии с mode.
+// В режиме BatchAtomic при наличии ошибок возвращается отчет и ErrBatchRejected
+func (s *OrderService) AcceptOrdersFromReader(r io.Reader, mode BatchMode, opts ImportOptions) (*BatchReport, error) {
+	return s.acceptOrdersFrom(r, "", mode, opts)
+}
+
+func (s *OrderService) acceptOrdersFrom(r io.Reader, filename string, mode BatchMode, opts ImportOptions) (*BatchReport, error) {
+	if _, err := ParseBatchMode(string(mode)); err != nil {
+		return nil, err
+	}
+
+	records, err := readOrders(r, filename, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.acceptBatch(records, mode)
+}
+
+// acceptBatch - проверяет записи пакета и принимает корректные заказы
+func (s *OrderService) acceptBatch(records []orderRecord, mode BatchMode) (*BatchReport, error) {
+	orders, results, err := s.validateBatch(records)
+	if err != nil {
+		return nil, err
+	}
+
+	invalid := 0
+	for _, result := range results {
+		if result.Status == AcceptStatusRejected {
+			invalid++
+		}
+	}
+
+	switch {
+	case mode == BatchBestEffort:
+		s.applyBestEffort(orders, results)
+	case invalid > 0:
+		skipValid(results)
+		err = ErrBatchRejected
+	default:
+		err = s.applyAtomic(orders, results)
+	}
+
+	return newBatchReport(mode, results), err
+}
+
+// validateBatch - проверяет все записи пакета, не изменяя репозиторий. Вместимость ПВЗ проверяется
+// с учетом предыдущих корректных записей пакета.
+// Для корректных записей возвращается готовый к сохранению заказ, для остальных - результат с ошибкой
+func (s *OrderService) validateBatch(records []orderRecord) ([]model.Order, []AcceptResult, error) {
+	usage, err := s.Utilization()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := s.clock.Now()
+	seen := make(map[int64]int, len(records))
+	orders := make([]model.Order, len(records))
+	results := make([]AcceptResult, len(records))
+
+	for i, record := range records {
+		results[i] = AcceptResult{Line: record.Line, OrderID: record.Data.ID}
+
+		order, err := s.validateRecord(record, now, seen)
+		if err == nil {
+			err = s.checkFits(usage, order)
+		}
+		if err != nil {
+			results[i].Status = AcceptStatusRejected
+			results[i].Error = err.Error()
+			continue
+		}
+
+		seen[order.ID] = record.Line
+		usage.add(order)
+		orders[i] = order
+	}
+
+	return orders, results, nil
+}
+
+func (s *OrderService) validateRecord(record orderRecord, now time.Time, seen map[int64]int) (model.Order, error) {
+	if record.Err != nil {
+		return model.Order{}, record.Err
+	}
+
+	data := record.Data
+	if line, ok := seen[data.ID]; ok {
+		return model.Order{}, fmt.Errorf("%w: Id %d уже указан в строке %d", ErrDuplicateInBatch, data.ID, line)
+	}
+
+	deadline, err := parseDeadline(data.DeadlineAt, now)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	packageType, wrappers := processPackaging(data.PackageType, data.Wrapper, data.Wrappers)
+
+	return s.buildOrder(data.ID, data.CustomerID, deadline, data.Weight, data.Cost, packageType, wrappers, data.acceptOptions(), now)
+}
+
+// applyAtomic - принимает все заказы пакета в одной транзакции
+func (s *OrderService) applyAtomic(orders []model.Order, results []AcceptResult) error {
+	failed := -1
+	err := s.withTx(func(tx *OrderService) error {
+		for i, order := range orders {
+			if err := tx.addOrder(order, commandAcceptOrdersFile); err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		skipValid(results)
+		if failed >= 0 {
+			results[failed].Status = AcceptStatusRejected
+			results[failed].Error = err.Error()
+		}
+		return fmt.Errorf("%w: %w", ErrBatchRejected, err)
+	}
+
+	for i := range results {
+		results[i].Status = AcceptStatusAccepted
+	}
+
+	return nil
+}
+
+// applyBestEffort - принимает корректные заказы пакета по одному
+func (s *OrderService) applyBestEffort(orders []model.Order, results []AcceptResult) {
+	for i, order := range orders {
+		if results[i].Status == AcceptStatusRejected {
+			continue
+		}
+
+		if err := s.addOrder(order, commandAcceptOrdersFile); err != nil {
+			results[i].Status = AcceptStatusRejected
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Status = AcceptStatusAccepted
+	}
+}
+
+// skipValid - помечает корректные записи отклоненного пакета как пропущенные
+func skipValid(results []AcceptResult) {
+	for i := range results {
+		if results[i].Status != AcceptStatusRejected {
+			results[i].Status = AcceptStatusSkipped
+		}
+	}
+}
+
+func newBatchReport(mode BatchMode, results []AcceptResult) *BatchReport {
+	report := &BatchReport{Mode: mode, Total: len(results), Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case AcceptStatusAccepted:
+			report.Accepted++
+		case AcceptStatusRejected:
+			report.Rejected++
+		case AcceptStatusSkipped:
+			report.Skipped++
+		}
+	}
+
+	return report
+}
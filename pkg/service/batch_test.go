@@ -0,0 +1,114 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// batchCSV - пакет из трех заказов клиента 1, второй с отрицательным весом
+const batchCSV = "id,customer_id,deadline_at,weight,cost\n" +
+	"1,1,48h,1,100\n" +
+	"2,1,48h,-1,100\n" +
+	"3,1,48h,1,100\n"
+
+// duplicateBatchCSV - пакет, в котором заказ 3 указан дважды
+const duplicateBatchCSV = "id,customer_id,deadline_at,weight,cost\n" +
+	"1,1,48h,1,100\n" +
+	"3,1,48h,1,100\n" +
+	"3,1,48h,1,100\n"
+
+func TestAcceptOrdersFromReader(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		mode         BatchMode
+		wantErr      error
+		wantStatuses []AcceptStatus
+		wantIDs      []int64
+	}{
+		{
+			name:         "атомарный пакет с ошибкой не принимается целиком",
+			input:        batchCSV,
+			mode:         BatchAtomic,
+			wantErr:      ErrBatchRejected,
+			wantStatuses: []AcceptStatus{AcceptStatusSkipped, AcceptStatusRejected, AcceptStatusSkipped},
+		},
+		{
+			name:         "частичный прием пропускает только ошибочную запись",
+			input:        batchCSV,
+			mode:         BatchBestEffort,
+			wantStatuses: []AcceptStatus{AcceptStatusAccepted, AcceptStatusRejected, AcceptStatusAccepted},
+			wantIDs:      []int64{1, 3},
+		},
+		{
+			name:         "атомарный пакет без ошибок",
+			input:        strings.Replace(batchCSV, "2,1,48h,-1", "2,1,48h,1", 1),
+			mode:         BatchAtomic,
+			wantStatuses: []AcceptStatus{AcceptStatusAccepted, AcceptStatusAccepted, AcceptStatusAccepted},
+			wantIDs:      []int64{1, 2, 3},
+		},
+		{
+			name:         "повтор заказа в файле",
+			input:        duplicateBatchCSV,
+			mode:         BatchBestEffort,
+			wantStatuses: []AcceptStatus{AcceptStatusAccepted, AcceptStatusAccepted, AcceptStatusRejected},
+			wantIDs:      []int64{1, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t, nil)
+
+			report, err := s.AcceptOrdersFromReader(strings.NewReader(tt.input), tt.mode, ImportOptions{Format: ImportCSV})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ошибка = %v, ожидалась %v", err, tt.wantErr)
+			}
+
+			var statuses []AcceptStatus
+			for _, result := range report.Results {
+				statuses = append(statuses, result.Status)
+			}
+			if !slices.Equal(statuses, tt.wantStatuses) {
+				t.Errorf("результаты %v, ожидалось %v", statuses, tt.wantStatuses)
+			}
+			if report.Total != len(tt.wantStatuses) || report.Accepted != len(tt.wantIDs) {
+				t.Errorf("отчет = %+v", report)
+			}
+
+			orders, err := s.Repo().List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids := orderIDs(orders); !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("в репозитории заказы %v, ожидалось %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestAcceptOrdersFromReaderExistingOrder(t *testing.T) {
+	for _, mode := range []BatchMode{BatchAtomic, BatchBestEffort} {
+		t.Run(string(mode), func(t *testing.T) {
+			s, _ := newTestService(t, nil)
+			acceptTestOrder(t, s, 3, 1)
+
+			input := "id,customer_id,deadline_at,weight,cost\n1,1,48h,1,100\n3,1,48h,1,100\n"
+			report, err := s.AcceptOrdersFromReader(strings.NewReader(input), mode, ImportOptions{Format: ImportCSV})
+			if mode == BatchAtomic && !errors.Is(err, ErrBatchRejected) {
+				t.Fatalf("ошибка = %v, ожидалась %v", err, ErrBatchRejected)
+			}
+			if report.Results[1].Status != AcceptStatusRejected || !strings.Contains(report.Results[1].Error, ErrOrderExists.Error()) {
+				t.Errorf("результат для принятого ранее заказа: %+v", report.Results[1])
+			}
+
+			// в атомарном режиме заказ 1 не принимается, в частичном - принимается
+			_, findErr := s.Repo().FindByID(1)
+			if accepted := findErr == nil; accepted != (mode == BatchBestEffort) {
+				t.Errorf("заказ 1 принят = %v в режиме %s", accepted, mode)
+			}
+		})
+	}
+}
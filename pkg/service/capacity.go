@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+var (
+	ErrCapacityExceeded = errors.New("превышена вместимость ПВЗ")
+)
+
+// storedStates - состояния заказов, которые физически находятся в ПВЗ: принятые от курьера и возвращенные
+// клиентами до передачи курьеру. Такие заказы занимают ячейки (возврату ячейка назначается при приеме от клиента)
+// и учитываются во вместимости, поэтому загрузка и схема ячеек считают одни и те же заказы
+var storedStates = []model.OrderState{model.StateAccepted, model.StateReturned}
+
+// Utilization - текущая загрузка ПВЗ заказами, которые физически в нем находятся, см. storedStates
+type Utilization struct {
+	Orders    int                       `json:"orders"`
+	Weight    float64                   `json:"weight"`
+	ByPackage map[model.PackageType]int `json:"by_package"`
+	// CellsUsed и CellsTotal - занятые и все ячейки хранения, если схема ячеек задана
+	CellsUsed  int `json:"cells_used"`
+	CellsTotal int `json:"cells_total"`
+}
+
+// Utilization - возвращает текущую загрузку ПВЗ
+func (s *OrderService) Utilization() (Utilization, error) {
+	usage := Utilization{ByPackage: make(map[model.PackageType]int)}
+	for _, state := range storedStates {
+		orders, err := s.repo.ListByState(state)
+		if err != nil {
+			return Utilization{}, err
+		}
+		for _, order := range orders {
+			usage.add(order)
+		}
+	}
+
+	loads, err := s.cellLoads()
+	if err != nil {
+		return Utilization{}, err
+	}
+	usage.CellsUsed = len(loads)
+	usage.CellsTotal = len(s.policy.CellLayout())
+
+	return usage, nil
+}
+
+// add - учитывает заказ в загрузке
+func (u *Utilization) add(order model.Order) {
+	u.Orders++
+	u.Weight += order.Weight
+	if order.PackageType != nil {
+		u.ByPackage[*order.PackageType]++
+	}
+}
+
+// checkFits - проверяет, поместится ли заказ в ПВЗ при текущей загрузке
+func (s *OrderService) checkFits(usage Utilization, order model.Order) error {
+	limits := s.policy.Capacity
+	if limits.MaxOrders > 0 && usage.Orders+1 > limits.MaxOrders {
+		return fmt.Errorf("%w: хранится заказов %d из %d", ErrCapacityExceeded, usage.Orders, limits.MaxOrders)
+	}
+	if limits.MaxWeight > 0 && usage.Weight+order.Weight > limits.MaxWeight {
+		return fmt.Errorf("%w: вес %.2f кг, хранится %.2f из %.2f кг", ErrCapacityExceeded, order.Weight, usage.Weight, limits.MaxWeight)
+	}
+	if order.PackageType == nil {
+		return nil
+	}
+
+	limit := limits.MaxByPackage[*order.PackageType]
+	if count := usage.ByPackage[*order.PackageType]; limit > 0 && count+1 > limit {
+		return fmt.Errorf("%w: заказов в упаковке %s %d из %d", ErrCapacityExceeded, *order.PackageType, count, limit)
+	}
+
+	return nil
+}
+
+// checkCapacity - проверяет, поместится ли заказ в ПВЗ
+func (s *OrderService) checkCapacity(order model.Order) error {
+	if !s.hasCapacityLimits() {
+		return nil
+	}
+
+	usage, err := s.Utilization()
+	if err != nil {
+		return err
+	}
+
+	return s.checkFits(usage, order)
+}
+
+func (s *OrderService) hasCapacityLimits() bool {
+	limits := s.policy.Capacity
+	return limits.MaxOrders > 0 || limits.MaxWeight > 0 || len(limits.MaxByPackage) > 0
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+func TestCapacityConcurrentAccept(t *testing.T) {
+	const n = 10
+
+	tests := []struct {
+		name     string
+		capacity policy.CapacitySpec
+	}{
+		{name: "число заказов", capacity: policy.CapacitySpec{MaxOrders: n - 1}},
+		{name: "суммарный вес", capacity: policy.CapacitySpec{MaxWeight: n - 1}},
+		{name: "заказы в упаковке", capacity: policy.CapacitySpec{MaxByPackage: map[model.PackageType]int{model.PackageBox: n - 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newConcurrentTestService(t, func(p *policy.Policy) {
+				p.Capacity = tt.capacity
+			})
+
+			// заказы весом 1 кг в коробке: последний не помещается
+			errs := acceptParallel(s, sequentialIDs(n))
+			if rejected := countErrors(t, errs, ErrCapacityExceeded); rejected != 1 {
+				t.Errorf("отклонено %d заказов, ожидался один", rejected)
+			}
+
+			usage, err := s.Utilization()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if usage.Orders != n-1 {
+				t.Errorf("хранится заказов %d, ожидалось %d", usage.Orders, n-1)
+			}
+		})
+	}
+}
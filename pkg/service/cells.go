@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+var (
+	ErrNoFreeCell = errors.New("нет свободной ячейки, подходящей по весу и типу упаковки")
+)
+
+// cellLoad - занятость ячейки заказами, находящимися в ПВЗ
+type cellLoad struct {
+	weight    float64
+	customers map[int64]bool
+}
+
+// assignCell - выбирает ячейку для принимаемого заказа или возврата от клиента. Если схема ячеек не задана, возвращает пустую строку.
+// Предпочтение отдается ячейке, где уже лежат заказы того же клиента, затем - самой маленькой подходящей
+func (s *OrderService) assignCell(order model.Order) (string, error) {
+	cells := s.policy.CellLayout()
+	if len(cells) == 0 {
+		return "", nil
+	}
+
+	loads, err := s.cellLoads()
+	if err != nil {
+		return "", err
+	}
+
+	var best *policy.Cell
+	bestShared := false
+	for i := range cells {
+		cell := &cells[i]
+		load := loads[cell.ID]
+		if !cell.Accepts(order.PackageType) || load.weight+order.Weight > cell.MaxWeight {
+			continue
+		}
+
+		shared := load.customers[order.CustomerID]
+		if best == nil || betterCell(cell, shared, best, bestShared) {
+			best, bestShared = cell, shared
+		}
+	}
+
+	if best == nil {
+		return "", fmt.Errorf("%w: вес %.2f, упаковка %s", ErrNoFreeCell, order.Weight, packageName(order.PackageType))
+	}
+
+	return best.ID, nil
+}
+
+// betterCell - проверяет, лучше ли ячейка candidate текущей лучшей ячейки best
+func betterCell(candidate *policy.Cell, candidateShared bool, best *policy.Cell, bestShared bool) bool {
+	if candidateShared != bestShared {
+		return candidateShared
+	}
+
+	return candidate.MaxWeight < best.MaxWeight
+}
+
+// cellLoads - возвращает занятость ячеек заказами, которые физически находятся в ПВЗ, см. storedStates
+func (s *OrderService) cellLoads() (map[string]cellLoad, error) {
+	var stored []model.Order
+	for _, state := range storedStates {
+		orders, err := s.repo.ListByState(state)
+		if err != nil {
+			return nil, err
+		}
+		stored = append(stored, orders...)
+	}
+
+	loads := make(map[string]cellLoad)
+	for _, order := range stored {
+		if order.Cell == "" {
+			continue
+		}
+
+		load := loads[order.Cell]
+		if load.customers == nil {
+			load.customers = make(map[int64]bool)
+		}
+		load.weight += order.Weight
+		load.customers[order.CustomerID] = true
+		loads[order.Cell] = load
+	}
+
+	return loads, nil
+}
+
+func packageName(packageType *model.PackageType) string {
+	if packageType == nil {
+		return "нет"
+	}
+
+	return string(*packageType)
+}
+
+// withCell - дополняет причину события ячейкой, в которую положен заказ
+func withCell(reason, cell string) string {
+	if cell == "" {
+		return reason
+	}
+
+	return reason + ", ячейка " + cell
+}
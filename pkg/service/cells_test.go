@@ -0,0 +1,175 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+func TestAssignCell(t *testing.T) {
+	s, _ := newTestService(t, func(p *policy.Policy) {
+		p.Cells = []policy.CellGroup{
+			{Shelf: "A", Count: 1, MaxWeight: 2},
+			{Shelf: "B", Count: 1, MaxWeight: 10},
+		}
+	})
+
+	// заказы принимаются по порядку, каждый следующий видит занятость от предыдущих
+	steps := []struct {
+		name       string
+		id         int64
+		customerID int64
+		weight     float64
+		wantCell   string
+		wantErr    error
+	}{
+		{name: "самая маленькая подходящая ячейка", id: 1, customerID: 1, weight: 1, wantCell: "A-01"},
+		{name: "тяжелый заказ в большую ячейку", id: 2, customerID: 2, weight: 5, wantCell: "B-01"},
+		{name: "ячейка с заказами того же клиента", id: 3, customerID: 2, weight: 1, wantCell: "B-01"},
+		{name: "нет ячейки с запасом по весу", id: 4, customerID: 3, weight: 5, wantErr: ErrNoFreeCell},
+	}
+
+	box := model.PackageBox
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			err := s.AcceptOrder(step.id, step.customerID, s.Now().Add(48*time.Hour), step.weight, 100, &box, nil, AcceptOptions{})
+			if step.wantErr != nil {
+				if !errors.Is(err, step.wantErr) {
+					t.Fatalf("ошибка = %v, ожидалась %v", err, step.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			order, err := s.Repo().FindByID(step.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order.Cell != step.wantCell {
+				t.Errorf("ячейка = %q, ожидалась %q", order.Cell, step.wantCell)
+			}
+		})
+	}
+}
+
+func TestCellLifecycle(t *testing.T) {
+	s, _ := newTestService(t, func(p *policy.Policy) {
+		p.Cells = []policy.CellGroup{{Shelf: "A", Count: 1, MaxWeight: 10}}
+	})
+	acceptTestOrder(t, s, 1, 1)
+
+	steps := []struct {
+		name      string
+		do        func() error
+		wantState model.OrderState
+		wantCell  string
+	}{
+		{
+			name:      "выдача освобождает ячейку",
+			do:        func() error { return s.DeliverOrder(1, 1, pickupCode(t, s, 1)) },
+			wantState: model.StateDelivered,
+		},
+		{
+			name:      "возврату от клиента назначается ячейка",
+			do:        func() error { return s.ProcessReturnOrder(1, 1) },
+			wantState: model.StateReturned,
+			wantCell:  "A-01",
+		},
+		{
+			name:      "передача курьеру освобождает ячейку",
+			do:        func() error { return s.ReturnOrderToCourier(1) },
+			wantState: model.StateReturnedToCourier,
+		},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			if err := step.do(); err != nil {
+				t.Fatal(err)
+			}
+
+			order, err := s.Repo().FindByID(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order.State != step.wantState || order.Cell != step.wantCell {
+				t.Errorf("состояние %q, ячейка %q; ожидалось %q, %q", order.State, order.Cell, step.wantState, step.wantCell)
+			}
+
+			// загрузка и схема ячеек учитывают одни и те же заказы
+			usage, err := s.Utilization()
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantUsed := 0
+			if step.wantCell != "" {
+				wantUsed = 1
+			}
+			if usage.Orders != wantUsed || usage.CellsUsed != wantUsed {
+				t.Errorf("загрузка: заказов %d, ячеек %d; ожидалось %d", usage.Orders, usage.CellsUsed, wantUsed)
+			}
+		})
+	}
+}
+
+func TestReturnWithoutFreeCell(t *testing.T) {
+	s, _ := newTestService(t, func(p *policy.Policy) {
+		p.Cells = []policy.CellGroup{{Shelf: "A", Count: 1, MaxWeight: 1}}
+	})
+	acceptTestOrder(t, s, 1, 1)
+	if err := s.DeliverOrder(1, 1, pickupCode(t, s, 1)); err != nil {
+		t.Fatal(err)
+	}
+	acceptTestOrder(t, s, 2, 2)
+
+	if err := s.ProcessReturnOrders([]int64{1}, 1); !errors.Is(err, ErrNoFreeCell) {
+		t.Fatalf("ошибка = %v, ожидалась %v", err, ErrNoFreeCell)
+	}
+
+	order, err := s.Repo().FindByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.State != model.StateDelivered || order.Cell != "" {
+		t.Errorf("состояние %q, ячейка %q после отказа в возврате", order.State, order.Cell)
+	}
+}
+
+func TestAssignCellConcurrent(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []int64
+		wantErr error
+	}{
+		{name: "последняя свободная ячейка", ids: sequentialIDs(20), wantErr: ErrNoFreeCell},
+		{name: "один и тот же заказ", ids: slices.Repeat([]int64{1}, 20), wantErr: ErrOrderExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newConcurrentTestService(t, func(p *policy.Policy) {
+				p.Cells = []policy.CellGroup{{Shelf: "A", Count: 1, MaxWeight: 1}}
+			})
+
+			errs := acceptParallel(s, tt.ids)
+			// принят ровно один заказ, остальные отклонены
+			if n := countErrors(t, errs, tt.wantErr); n != len(errs)-1 {
+				t.Errorf("ошибок %v: %d из %d, ожидалось %d", tt.wantErr, n, len(errs), len(errs)-1)
+			}
+
+			usage, err := s.Utilization()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if usage.Orders != 1 || usage.CellsUsed != 1 {
+				t.Errorf("загрузка: заказов %d, ячеек %d; ожидался один заказ в одной ячейке", usage.Orders, usage.CellsUsed)
+			}
+		})
+	}
+}
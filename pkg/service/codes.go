@@ -0,0 +1,214 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+var (
+	ErrWrongPickupCode = errors.New("неверный код выдачи")
+	ErrPickupLocked    = errors.New("выдача заказа временно заблокирована после неверных попыток ввода кода")
+	ErrNoPickupCode    = errors.New("у заказа нет кода выдачи, выпустите новый командой reissue_code")
+	ErrCodeNotIssuable = errors.New("код выдачи можно выпустить только для заказа, хранящегося в ПВЗ")
+)
+
+const (
+	pickupCodeDigits = 6
+	pickupSaltSize   = 8
+)
+
+// Handout - заказ к выдаче и код выдачи, который назвал клиент
+type Handout struct {
+	OrderID int64
+	Code    string
+}
+
+// ReissuePickupCode - выпускает новый код выдачи взамен утерянного и снимает блокировку выдачи.
+// Прежний код перестает действовать, новый передается в выгрузку для уведомления клиента
+func (s *OrderService) ReissuePickupCode(id int64) error {
+	return s.withTx(func(tx *OrderService) error {
+		return tx.reissuePickupCode(id)
+	})
+}
+
+// reissuePickupCode - выпускает новый код выдачи. Вызывается в транзакции, чтобы код не был выпущен
+// для заказа, который в это время выдают или возвращают
+func (s *OrderService) reissuePickupCode(id int64) error {
+	order, err := s.repo.FindByID(id)
+	if err != nil {
+		return fmt.Errorf("ошибка при выпуске кода выдачи заказа Id %d: %w", id, err)
+	}
+	if order.State != model.StateAccepted {
+		return fmt.Errorf("%w: ID %d в состоянии %s", ErrCodeNotIssuable, id, order.State)
+	}
+
+	notice, err := s.issuePickupCode(&order)
+	if err != nil {
+		return err
+	}
+	order.UpdatedAt = notice.IssuedAt
+	if err = s.repo.Update(order); err != nil {
+		return err
+	}
+
+	if err = s.recordEvent(id, order.State, order.State, commandReissueCode, "выпущен новый код выдачи"); err != nil {
+		return err
+	}
+
+	return s.sendCode(notice)
+}
+
+// issuePickupCode - генерирует одноразовый код выдачи, сохраняет в заказе его хеш и сбрасывает
+// счетчик неверных попыток. Возвращает уведомление с кодом в открытом виде
+func (s *OrderService) issuePickupCode(order *model.Order) (model.PickupNotice, error) {
+	code, err := newPickupCode()
+	if err != nil {
+		return model.PickupNotice{}, err
+	}
+	hash, err := hashPickupCode(order.ID, code)
+	if err != nil {
+		return model.PickupNotice{}, err
+	}
+
+	order.PickupCodeHash = hash
+	order.PickupAttempts = 0
+	order.PickupLockedUntil = nil
+
+	return model.PickupNotice{
+		OrderID:    order.ID,
+		CustomerID: order.CustomerID,
+		PointID:    s.point,
+		Code:       code,
+		DeadlineAt: order.DeadlineAt,
+		IssuedAt:   s.clock.Now(),
+	}, nil
+}
+
+// sendCode - передает код выдачи в выгрузку. Внутри транзакции код откладывается до ее успешного завершения
+func (s *OrderService) sendCode(notice model.PickupNotice) error {
+	if s.pending != nil {
+		s.pending.codes = append(s.pending.codes, notice)
+		return nil
+	}
+
+	if err := s.codes.Send(notice); err != nil {
+		return fmt.Errorf("ошибка выгрузки кода выдачи заказа %d: %w", notice.OrderID, err)
+	}
+
+	return nil
+}
+
+// verifyHandouts - проверяет коды выдачи заказов клиента и сохраняет первую неверную попытку
+func (s *OrderService) verifyHandouts(handouts []Handout, customerID int64) error {
+	now := s.clock.Now()
+	for _, handout := range handouts {
+		order, err := s.repo.FindByID(handout.OrderID)
+		if err != nil {
+			return fmt.Errorf("ошибка при доставке заказа Id %d: %w", handout.OrderID, err)
+		}
+		if order.CustomerID != customerID {
+			return fmt.Errorf("%w: ID %d", ErrWrongCustomer, handout.OrderID)
+		}
+		// состояние заказа проверяется при выдаче
+		if order.State != model.StateAccepted {
+			continue
+		}
+
+		if err = s.verifyPickupCode(order, handout.Code, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifyPickupCode - сверяет код с хешем заказа и учитывает неверную попытку
+func (s *OrderService) verifyPickupCode(order model.Order, code string, now time.Time) error {
+	if order.PickupLockedUntil != nil && now.Before(*order.PickupLockedUntil) {
+		return fmt.Errorf("%w: ID %d, до %s", ErrPickupLocked, order.ID, order.PickupLockedUntil.Format(timeLayout))
+	}
+	if order.PickupCodeHash == "" {
+		return fmt.Errorf("%w: ID %d", ErrNoPickupCode, order.ID)
+	}
+	if pickupCodeMatches(order.PickupCodeHash, order.ID, code) {
+		return nil
+	}
+
+	return s.registerFailedAttempt(order, now)
+}
+
+// registerFailedAttempt - сохраняет неверную попытку ввода кода, а после исчерпания попыток блокирует выдачу
+func (s *OrderService) registerFailedAttempt(order model.Order, now time.Time) error {
+	limits := s.policy.PickupCode
+	order.PickupAttempts++
+	attempt := order.PickupAttempts
+	reason := fmt.Sprintf("неверный код выдачи, попытка %d из %d", attempt, limits.MaxAttempts)
+	if attempt >= limits.MaxAttempts {
+		lockedUntil := now.Add(limits.Lockout.Duration)
+		order.PickupLockedUntil = &lockedUntil
+		order.PickupAttempts = 0
+		reason += ", выдача заблокирована до " + lockedUntil.Format(timeLayout)
+	}
+
+	if err := s.repo.Update(order); err != nil {
+		return err
+	}
+	if err := s.recordEvent(order.ID, order.State, order.State, commandHandout, reason); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: ID %d, попытка %d из %d", ErrWrongPickupCode, order.ID, attempt, limits.MaxAttempts)
+}
+
+// newPickupCode - генерирует случайный цифровой код выдачи
+func newPickupCode() (string, error) {
+	limit := big.NewInt(1)
+	for range pickupCodeDigits {
+		limit.Mul(limit, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", fmt.Errorf("ошибка генерации кода выдачи: %w", err)
+	}
+
+	return fmt.Sprintf("%0*d", pickupCodeDigits, n), nil
+}
+
+// hashPickupCode - возвращает хеш кода выдачи со случайной солью в виде "соль$хеш"
+func hashPickupCode(orderID int64, code string) (string, error) {
+	salt := make([]byte, pickupSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("ошибка генерации кода выдачи: %w", err)
+	}
+	encodedSalt := hex.EncodeToString(salt)
+
+	return encodedSalt + "$" + pickupCodeDigest(encodedSalt, orderID, code), nil
+}
+
+// pickupCodeMatches - сравнивает код с сохраненным хешем за постоянное время
+func pickupCodeMatches(stored string, orderID int64, code string) bool {
+	salt, digest, ok := strings.Cut(stored, "$")
+	if !ok {
+		return false
+	}
+
+	expected := pickupCodeDigest(salt, orderID, strings.TrimSpace(code))
+
+	return subtle.ConstantTimeCompare([]byte(digest), []byte(expected)) == 1
+}
+
+func pickupCodeDigest(salt string, orderID int64, code string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d:%s", salt, orderID, code)))
+
+	return hex.EncodeToString(sum[:])
+}
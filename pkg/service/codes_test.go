@@ -0,0 +1,195 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/notify"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+func TestPickupCodeLockout(t *testing.T) {
+	s, fake := newTestService(t, func(p *policy.Policy) {
+		p.PickupCode = policy.PickupCodeSpec{MaxAttempts: 3, Lockout: policy.Duration{Duration: 15 * time.Minute}}
+	})
+	acceptTestOrder(t, s, 1, 1)
+	code := pickupCode(t, s, 1)
+
+	// шаги выполняются по порядку над одним заказом
+	steps := []struct {
+		name string
+		// wait - сколько пройдет времени перед попыткой
+		wait         time.Duration
+		code         string
+		wantErr      error
+		wantAttempts int
+		wantLocked   bool
+	}{
+		{name: "первая неверная попытка", code: "000000", wantErr: ErrWrongPickupCode, wantAttempts: 1},
+		{name: "вторая неверная попытка", code: "000000", wantErr: ErrWrongPickupCode, wantAttempts: 2},
+		{name: "последняя попытка блокирует выдачу", code: "000000", wantErr: ErrWrongPickupCode, wantLocked: true},
+		{name: "верный код во время блокировки", code: code, wantErr: ErrPickupLocked, wantLocked: true},
+		{name: "верный код до конца блокировки", wait: 15*time.Minute - time.Second, code: code, wantErr: ErrPickupLocked, wantLocked: true},
+		{name: "после блокировки попытки считаются заново", wait: time.Second, code: "000000", wantErr: ErrWrongPickupCode, wantAttempts: 1, wantLocked: true},
+		{name: "верный код после блокировки", code: code},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			fake.Advance(step.wait)
+			if err := s.DeliverOrder(1, 1, step.code); !errors.Is(err, step.wantErr) {
+				t.Fatalf("ошибка = %v, ожидалась %v", err, step.wantErr)
+			}
+
+			order, err := s.Repo().FindByID(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order.PickupAttempts != step.wantAttempts || (order.PickupLockedUntil != nil) != step.wantLocked {
+				t.Errorf("попыток %d, блокировка до %v; ожидалось %d, блокировка %v",
+					order.PickupAttempts, order.PickupLockedUntil, step.wantAttempts, step.wantLocked)
+			}
+			if step.wantErr == nil && (order.State != model.StateDelivered || order.PickupCodeHash != "") {
+				t.Errorf("после выдачи состояние %q, хеш кода %q", order.State, order.PickupCodeHash)
+			}
+		})
+	}
+}
+
+func TestReissuePickupCodeUnlocks(t *testing.T) {
+	s, _ := newTestService(t, func(p *policy.Policy) {
+		p.PickupCode = policy.PickupCodeSpec{MaxAttempts: 1, Lockout: policy.Duration{Duration: time.Hour}}
+	})
+	acceptTestOrder(t, s, 1, 1)
+
+	if err := s.DeliverOrder(1, 1, "000000"); !errors.Is(err, ErrWrongPickupCode) {
+		t.Fatalf("ошибка = %v, ожидалась %v", err, ErrWrongPickupCode)
+	}
+	if err := s.ReissuePickupCode(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// новый код выдает заказ, не дожидаясь конца блокировки
+	if err := s.DeliverOrder(1, 1, pickupCode(t, s, 1)); err != nil {
+		t.Fatalf("новый код: %v", err)
+	}
+}
+
+func TestReissuePickupCodeNotIssuable(t *testing.T) {
+	s, _ := newTestService(t, nil)
+	acceptTestOrder(t, s, 1, 1)
+	if err := s.DeliverOrder(1, 1, pickupCode(t, s, 1)); err != nil {
+		t.Fatal(err)
+	}
+	notices := len(s.codes.(*notify.MemoryOutbox).Notices())
+
+	// выданному заказу код не выпускается, заказ и выгрузка не меняются
+	if err := s.ReissuePickupCode(1); !errors.Is(err, ErrCodeNotIssuable) {
+		t.Fatalf("ошибка = %v, ожидалась %v", err, ErrCodeNotIssuable)
+	}
+
+	order, err := s.Repo().FindByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.State != model.StateDelivered || order.PickupCodeHash != "" {
+		t.Errorf("состояние %q, хеш кода %q", order.State, order.PickupCodeHash)
+	}
+	if got := len(s.codes.(*notify.MemoryOutbox).Notices()); got != notices {
+		t.Errorf("уведомлений %d, ожидалось %d", got, notices)
+	}
+}
+
+func TestReissuePickupCodeParallelDelivery(t *testing.T) {
+	for range 20 {
+		s := newConcurrentTestService(t, nil)
+		acceptTestOrder(t, s, 1, 1)
+		code := pickupCode(t, s, 1)
+
+		var deliverErr, reissueErr error
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-start
+			deliverErr = s.DeliverOrder(1, 1, code)
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			reissueErr = s.ReissuePickupCode(1)
+		}()
+		close(start)
+		wg.Wait()
+
+		order, err := s.Repo().FindByID(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// либо заказ выдан и код для него не выпущен, либо новый код выпущен до выдачи и прежний не подошел
+		switch {
+		case deliverErr == nil:
+			if !errors.Is(reissueErr, ErrCodeNotIssuable) || order.State != model.StateDelivered || order.PickupCodeHash != "" {
+				t.Fatalf("после выдачи: выпуск кода %v, состояние %q, хеш кода %q", reissueErr, order.State, order.PickupCodeHash)
+			}
+		case errors.Is(deliverErr, ErrWrongPickupCode):
+			if reissueErr != nil || order.State != model.StateAccepted {
+				t.Fatalf("после выпуска кода: %v, состояние %q", reissueErr, order.State)
+			}
+		default:
+			t.Fatalf("выдача: %v, выпуск кода: %v", deliverErr, reissueErr)
+		}
+	}
+}
+
+func TestPickupCodeParallelAttempts(t *testing.T) {
+	const maxAttempts = 5
+
+	s := newConcurrentTestService(t, func(p *policy.Policy) {
+		p.PickupCode = policy.PickupCodeSpec{MaxAttempts: maxAttempts, Lockout: policy.Duration{Duration: time.Hour}}
+	})
+	acceptTestOrder(t, s, 1, 1)
+
+	errs := make([]error, 2*maxAttempts)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = s.DeliverOrder(1, 1, "000000")
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	// каждая попытка учтена: после maxAttempts неверных кодов выдача заблокирована
+	wrong, locked := 0, 0
+	for _, err := range errs {
+		switch {
+		case errors.Is(err, ErrWrongPickupCode):
+			wrong++
+		case errors.Is(err, ErrPickupLocked):
+			locked++
+		default:
+			t.Errorf("неожиданная ошибка: %v", err)
+		}
+	}
+	if wrong != maxAttempts || locked != maxAttempts {
+		t.Errorf("неверных попыток %d, отказов из-за блокировки %d; ожидалось по %d", wrong, locked, maxAttempts)
+	}
+
+	order, err := s.Repo().FindByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.PickupLockedUntil == nil {
+		t.Error("выдача не заблокирована")
+	}
+}
@@ -4,37 +4,52 @@ import (
 	"errors"
 
 	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/packaging"
 )
 
 var (
 	ErrUnknownWrapperType = errors.New("неизвестный тип обертки")
 )
 
+// wrapperDecorator - обертка поверх упаковщика. Обертки складываются в цепочку:
+// каждая добавляет свою стоимость и может изменить ограничение веса
 type wrapperDecorator struct {
 	packager    packager
 	description string
 	cost        float64
+	// maxWeightAdjustment - на сколько кг обертка увеличивает максимальный вес упаковки
+	maxWeightAdjustment float64
 }
 
-func newWrapperDecorator(packager packager, wrapperType model.WrapperType) (*wrapperDecorator, error) {
-	switch wrapperType {
-	case model.WrapperFilm:
-		return &wrapperDecorator{
-			packager:    packager,
-			description: "film",
-			cost:        filmCost,
-		}, nil
-	default:
+func newWrapperDecorator(packager packager, wrapperType model.WrapperType, registry *packaging.Registry) (*wrapperDecorator, error) {
+	wrapper, ok := registry.Wrapper(wrapperType)
+	if !ok {
 		return nil, ErrUnknownWrapperType
 	}
+
+	return &wrapperDecorator{
+		packager:            packager,
+		description:         string(wrapper.Name),
+		cost:                wrapper.Cost,
+		maxWeightAdjustment: wrapper.MaxWeightAdjustment,
+	}, nil
 }
 
+// validateWeight - проверяет вес по ограничению нижележащей упаковки, сдвинутому на maxWeightAdjustment
 func (d *wrapperDecorator) validateWeight(weight float64) error {
-	return d.packager.validateWeight(weight)
+	return d.packager.validateWeight(weight - d.maxWeightAdjustment)
+}
+
+func (d *wrapperDecorator) validateDimensions(dimensions *model.Dimensions) error {
+	return d.packager.validateDimensions(dimensions)
+}
+
+func (d *wrapperDecorator) chargeableWeight(weight float64, dimensions *model.Dimensions) float64 {
+	return d.packager.chargeableWeight(weight, dimensions)
 }
 
-func (d *wrapperDecorator) getAdditionalCost() float64 {
-	return d.packager.getAdditionalCost() + d.cost
+func (d *wrapperDecorator) getAdditionalCost(weight float64) float64 {
+	return d.packager.getAdditionalCost(weight) + d.cost
 }
 
 func (d *wrapperDecorator) getDescription() string {
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/packaging"
+)
+
+const (
+	testCrate model.PackageType = "crate"
+	testStrap model.WrapperType = "strap"
+	testTape  model.WrapperType = "tape"
+)
+
+// testRegistry - ящик до 20 кг за 10 и 2 за кг; ремень добавляет 5 кг к ограничению веса, лента снимает 2 кг
+func testRegistry() *packaging.Registry {
+	r := packaging.NewRegistry()
+	r.RegisterPackage(packaging.Package{Name: testCrate, Cost: 10, CostPerKg: 2, MaxWeight: 20})
+	r.RegisterWrapper(packaging.Wrapper{Name: testStrap, Cost: 3, MaxWeightAdjustment: 5})
+	r.RegisterWrapper(packaging.Wrapper{Name: testTape, Cost: 1, MaxWeightAdjustment: -2})
+
+	return r
+}
+
+// weightRecorder - упаковщик, запоминающий вес, переданный в validateWeight
+type weightRecorder struct {
+	packager
+	weight float64
+}
+
+func (r *weightRecorder) validateWeight(weight float64) error {
+	r.weight = weight
+	return nil
+}
+
+func TestPackagerChain(t *testing.T) {
+	tests := []struct {
+		name     string
+		wrappers []model.WrapperType
+		wantDesc string
+		// wantCost - стоимость упаковки заказа весом 5 кг
+		wantCost float64
+		// wantMaxWeight - наибольший допустимый вес
+		wantMaxWeight float64
+	}{
+		{name: "без оберток", wantDesc: "crate", wantCost: 20, wantMaxWeight: 20},
+		{name: "одна обертка", wrappers: []model.WrapperType{testStrap}, wantDesc: "crate + strap", wantCost: 23, wantMaxWeight: 25},
+		{name: "две обертки", wrappers: []model.WrapperType{testStrap, testTape}, wantDesc: "crate + strap + tape", wantCost: 24, wantMaxWeight: 23},
+		{name: "обратный порядок", wrappers: []model.WrapperType{testTape, testStrap}, wantDesc: "crate + tape + strap", wantCost: 24, wantMaxWeight: 23},
+	}
+
+	crate := testCrate
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newPackagerFactory(testRegistry()).createPackager(&crate, tt.wrappers)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// обертки перечисляются в порядке наложения, их стоимость складывается
+			if got := p.getDescription(); got != tt.wantDesc {
+				t.Errorf("описание %q, ожидалось %q", got, tt.wantDesc)
+			}
+			if got := p.getAdditionalCost(5); got != tt.wantCost {
+				t.Errorf("стоимость %v, ожидалось %v", got, tt.wantCost)
+			}
+			if err = p.validateWeight(tt.wantMaxWeight); err != nil {
+				t.Errorf("вес %v: %v", tt.wantMaxWeight, err)
+			}
+			if err = p.validateWeight(tt.wantMaxWeight + 0.5); !errors.Is(err, ErrPackageWeightExceeded) {
+				t.Errorf("вес %v: ошибка = %v, ожидалась %v", tt.wantMaxWeight+0.5, err, ErrPackageWeightExceeded)
+			}
+		})
+	}
+}
+
+func TestWrapperDecoratorAdjustsWeight(t *testing.T) {
+	tests := []struct {
+		name     string
+		wrappers []model.WrapperType
+		want     float64
+	}{
+		{name: "без оберток", want: 10},
+		{name: "увеличение ограничения", wrappers: []model.WrapperType{testStrap}, want: 5},
+		{name: "уменьшение ограничения", wrappers: []model.WrapperType{testTape}, want: 12},
+		{name: "сдвиги складываются", wrappers: []model.WrapperType{testStrap, testTape}, want: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &weightRecorder{}
+			var p packager = base
+			for _, wrapper := range tt.wrappers {
+				decorated, err := newWrapperDecorator(p, wrapper, testRegistry())
+				if err != nil {
+					t.Fatal(err)
+				}
+				p = decorated
+			}
+
+			// упаковка проверяет вес, уменьшенный на сдвиги ограничения всех оберток
+			if err := p.validateWeight(10); err != nil {
+				t.Fatal(err)
+			}
+			if base.weight != tt.want {
+				t.Errorf("упаковка проверила вес %v, ожидалось %v", base.weight, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreatePackagerUnknownWrapper(t *testing.T) {
+	crate := testCrate
+	_, err := newPackagerFactory(testRegistry()).createPackager(&crate, []model.WrapperType{testStrap, "rope"})
+	if !errors.Is(err, ErrUnknownWrapperType) {
+		t.Errorf("ошибка = %v, ожидалась %v", err, ErrUnknownWrapperType)
+	}
+}
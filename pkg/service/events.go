@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+// Команды, от имени которых записываются события заказов
+const (
+	commandAcceptOrder      = "accept_order"
+	commandAcceptOrdersFile = "accept_orders_file"
+	commandReturnToCourier  = "return_to_courier"
+	commandHandout          = "process_customer handout"
+	commandCustomerReturn   = "process_customer return"
+	commandTransferOrder    = "transfer_order"
+	commandExtendStorage    = "extend_storage"
+	commandReissueCode      = "reissue_code"
+)
+
+const defaultActor = "system"
+
+// ForActor - возвращает копию сервиса, записывающую события от имени actor
+func (s *OrderService) ForActor(actor string) *OrderService {
+	if actor == "" {
+		actor = defaultActor
+	}
+
+	scoped := *s
+	scoped.actor = actor
+
+	return &scoped
+}
+
+// OrderEvents - возвращает историю изменений заказа, в том числе уже переданного курьеру.
+// Если выбран пункт выдачи, история доступна только для его заказов
+func (s *OrderService) OrderEvents(orderID int64) ([]model.OrderEvent, error) {
+	if s.point != "" {
+		if _, err := s.repo.FindByID(orderID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.events.ListByOrder(orderID)
+}
+
+// recordEvent - записывает событие смены состояния заказа.
+// Внутри транзакции событие откладывается до ее успешного завершения
+func (s *OrderService) recordEvent(orderID int64, from, to model.OrderState, command, reason string) error {
+	event := model.OrderEvent{
+		OrderID:   orderID,
+		At:        s.clock.Now(),
+		Actor:     s.actor,
+		FromState: from,
+		ToState:   to,
+		Command:   command,
+		Reason:    reason,
+	}
+
+	if s.pending != nil {
+		s.pending.events = append(s.pending.events, event)
+		return nil
+	}
+
+	if err := s.events.Append(event); err != nil {
+		return fmt.Errorf("ошибка записи события заказа %d: %w", orderID, err)
+	}
+
+	return nil
+}
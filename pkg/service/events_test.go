@@ -0,0 +1,68 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/clock"
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/repository"
+)
+
+// failingLog - журнал событий, запись в который всегда завершается ошибкой
+type failingLog struct{}
+
+func (failingLog) Append(...model.OrderEvent) error {
+	return errors.New("диск переполнен")
+}
+
+func (failingLog) ListByOrder(int64) ([]model.OrderEvent, error) {
+	return nil, nil
+}
+
+func TestEventsInRepositoryTx(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.db")
+	repo, err := repository.NewSQLRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	s := NewOrderService(repo, WithEventLog(repo), WithClock(clock.NewFake(testNow)), WithPolicy(testPolicy(t, nil)))
+
+	acceptTestOrder(t, s, 1, 1)
+	if events, err := s.OrderEvents(1); err != nil || len(events) != 1 {
+		t.Fatalf("события заказа 1 = %+v, %v", events, err)
+	}
+
+	// событие не удается записать - заказ не сохраняется вместе с ним
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err = db.Exec(`DROP TABLE order_events`); err != nil {
+		t.Fatal(err)
+	}
+
+	box := model.PackageBox
+	if err = s.AcceptOrder(2, 1, s.Now().Add(48*time.Hour), 1, 100, &box, nil, AcceptOptions{}); err == nil {
+		t.Fatal("прием без записи события завершился без ошибки")
+	}
+	if _, err = s.Repo().FindByID(2); !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Errorf("заказ сохранен без события: %v", err)
+	}
+}
+
+func TestSeparateEventLogFailureKeepsCommittedOrder(t *testing.T) {
+	s := NewOrderService(repository.NewInMemoryRepository(), WithEventLog(failingLog{}),
+		WithClock(clock.NewFake(testNow)), WithPolicy(testPolicy(t, nil)))
+
+	// изменения заказа уже зафиксированы, поэтому сбой отдельного журнала не возвращается как ошибка
+	order := acceptTestOrder(t, s, 1, 1)
+	if order.State != model.StateAccepted {
+		t.Errorf("состояние %q, ожидалось %q", order.State, model.StateAccepted)
+	}
+}
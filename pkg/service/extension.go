@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+var (
+	ErrNotExtendable     = errors.New("продлить срок можно только заказу, хранящемуся в ПВЗ")
+	ErrExtensionNotLater = errors.New("новый срок хранения должен быть позже текущего")
+	ErrExtensionLimit    = errors.New("превышено ограничение на продление срока хранения")
+)
+
+// ExtendStorage - продлевает срок хранения принятого заказа. extension - длительность, добавляемая
+// к текущему сроку ("48h"), или новый срок в формате "YYYY-MM-DDTHH:MM:SS". Число и суммарная длительность
+// продлений ограничены политикой, плата за продление добавляется к стоимости заказа.
+// Проверка ограничений и сохранение выполняются в одной транзакции. Возвращает заказ с новым сроком хранения
+func (s *OrderService) ExtendStorage(id int64, extension string) (model.Order, error) {
+	var extended model.Order
+	err := s.withTx(func(tx *OrderService) error {
+		var err error
+		extended, err = tx.extendStorage(id, extension)
+		return err
+	})
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	return extended, nil
+}
+
+// extendStorage - продлевает срок хранения заказа, см. ExtendStorage
+func (s *OrderService) extendStorage(id int64, extension string) (model.Order, error) {
+	now := s.clock.Now()
+	order, err := s.repo.FindByID(id)
+	if err != nil {
+		return model.Order{}, fmt.Errorf("ошибка при продлении хранения заказа Id %d: %w", id, err)
+	}
+	if order.State != model.StateAccepted {
+		return model.Order{}, fmt.Errorf("%w: ID %d в состоянии %s", ErrNotExtendable, id, order.State)
+	}
+	if now.After(order.DeadlineAt) {
+		return model.Order{}, fmt.Errorf("%w: %v \n Текущая дата: %v", ErrStorageExpired, order.DeadlineAt, now)
+	}
+
+	deadline, err := parseDeadline(extension, order.DeadlineAt)
+	if err != nil {
+		return model.Order{}, err
+	}
+	if err = s.checkExtension(order, deadline); err != nil {
+		return model.Order{}, err
+	}
+
+	fee := s.policy.Extension.Fee
+	order.ExtendedFor += deadline.Sub(order.DeadlineAt)
+	order.Extensions++
+	order.DeadlineAt = deadline
+	addCharge(&order, ruleExtension, "Продление срока хранения до "+deadline.Format(timeLayout), fee)
+	order.UpdatedAt = now
+	if err = s.repo.Update(order); err != nil {
+		return model.Order{}, err
+	}
+
+	reason := "срок хранения продлен до " + deadline.Format(timeLayout)
+	if fee > 0 {
+		reason += fmt.Sprintf(", плата %.2f", fee)
+	}
+	if err = s.recordEvent(id, order.State, order.State, commandExtendStorage, reason); err != nil {
+		return model.Order{}, err
+	}
+
+	return order, nil
+}
+
+// checkExtension - проверяет, что новый срок позже текущего и продление укладывается в ограничения политики
+func (s *OrderService) checkExtension(order model.Order, deadline time.Time) error {
+	if !deadline.After(order.DeadlineAt) {
+		return fmt.Errorf("%w: %s, текущий срок: %s", ErrExtensionNotLater, deadline.Format(timeLayout), order.DeadlineAt.Format(timeLayout))
+	}
+
+	limits := s.policy.Extension
+	if limits.MaxCount > 0 && order.Extensions+1 > limits.MaxCount {
+		return fmt.Errorf("%w: срок уже продлевался %d раз из %d", ErrExtensionLimit, order.Extensions, limits.MaxCount)
+	}
+	total := order.ExtendedFor + deadline.Sub(order.DeadlineAt)
+	if limits.MaxTotal.Duration > 0 && total > limits.MaxTotal.Duration {
+		return fmt.Errorf("%w: суммарное продление %v больше допустимого %v", ErrExtensionLimit, total, limits.MaxTotal.Duration)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+func TestExtendStorage(t *testing.T) {
+	s, _ := newTestService(t, func(p *policy.Policy) {
+		p.Extension = policy.ExtensionSpec{MaxCount: 2, MaxTotal: policy.Duration{Duration: 72 * time.Hour}, Fee: 50}
+	})
+	accepted := acceptTestOrder(t, s, 1, 1)
+	acceptTestOrder(t, s, 2, 2)
+	if err := s.DeliverOrder(2, 2, pickupCode(t, s, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	// продления выполняются по порядку; после отказа заказ не меняется
+	steps := []struct {
+		name      string
+		id        int64
+		extension string
+		wantErr   error
+		// wantExtended - суммарное продление срока заказа 1 после шага
+		wantExtended time.Duration
+		wantCount    int
+	}{
+		{name: "выданный заказ", id: 2, extension: "24h", wantErr: ErrNotExtendable},
+		{name: "срок не позже текущего", id: 1, extension: "0h", wantErr: ErrExtensionNotLater},
+		{name: "первое продление", id: 1, extension: "24h", wantExtended: 24 * time.Hour, wantCount: 1},
+		{name: "превышена суммарная длительность", id: 1, extension: "49h", wantErr: ErrExtensionLimit, wantExtended: 24 * time.Hour, wantCount: 1},
+		{name: "второе продление", id: 1, extension: "24h", wantExtended: 48 * time.Hour, wantCount: 2},
+		{name: "превышено число продлений", id: 1, extension: "1h", wantErr: ErrExtensionLimit, wantExtended: 48 * time.Hour, wantCount: 2},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			if _, err := s.ExtendStorage(step.id, step.extension); !errors.Is(err, step.wantErr) {
+				t.Fatalf("ошибка = %v, ожидалась %v", err, step.wantErr)
+			}
+
+			order, err := s.Repo().FindByID(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// плата за каждое продление добавляется к стоимости заказа
+			wantCost := accepted.Cost + 50*float64(step.wantCount)
+			if order.Extensions != step.wantCount || order.ExtendedFor != step.wantExtended || order.Cost != wantCost {
+				t.Errorf("продлений %d на %v, стоимость %v; ожидалось %d на %v, %v",
+					order.Extensions, order.ExtendedFor, order.Cost, step.wantCount, step.wantExtended, wantCost)
+			}
+			if want := accepted.DeadlineAt.Add(step.wantExtended); !order.DeadlineAt.Equal(want) {
+				t.Errorf("срок хранения %v, ожидался %v", order.DeadlineAt, want)
+			}
+		})
+	}
+}
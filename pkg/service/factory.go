@@ -2,8 +2,10 @@ package service
 
 import (
 	"errors"
+	"fmt"
 
 	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/packaging"
 )
 
 var (
@@ -11,39 +13,37 @@ var (
 )
 
 type packagerFactory interface {
-	createPackager(baseType *model.PackageType, wrappers *model.WrapperType) (packager, error)
+	createPackager(baseType *model.PackageType, wrappers []model.WrapperType) (packager, error)
 }
 
-type defaultPackagerFactory struct{}
+// defaultPackagerFactory - создает упаковщики по типам, зарегистрированным в реестре упаковки
+type defaultPackagerFactory struct {
+	registry *packaging.Registry
+}
 
-func newPackagerFactory() packagerFactory {
-	return &defaultPackagerFactory{}
+func newPackagerFactory(r *packaging.Registry) packagerFactory {
+	return &defaultPackagerFactory{registry: r}
 }
 
-func (f *defaultPackagerFactory) createPackager(baseType *model.PackageType, wrapper *model.WrapperType) (packager, error) {
+// createPackager - создает упаковщик типа baseType, обернутый обертками wrappers в указанном порядке
+func (f *defaultPackagerFactory) createPackager(baseType *model.PackageType, wrappers []model.WrapperType) (packager, error) {
 	if baseType == nil {
 		return nil, ErrUnknownPackageType
 	}
 
-	var basePackager packager
-	switch *baseType {
-	case model.PackageBag:
-		basePackager = newBagPackager()
-	case model.PackageBox:
-		basePackager = newBoxPackager()
-	case model.PackageFilm:
-		basePackager = newFilmPackager()
-	default:
+	pkg, ok := f.registry.Package(*baseType)
+	if !ok {
 		return nil, ErrUnknownPackageType
 	}
+	var result packager = newBasicPackager(pkg)
 
-	if wrapper != nil {
-		decorated, err := newWrapperDecorator(basePackager, *wrapper)
+	for _, wrapper := range wrappers {
+		decorated, err := newWrapperDecorator(result, wrapper, f.registry)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %s", err, wrapper)
 		}
-		return decorated, nil
+		result = decorated
 	}
 
-	return basePackager, nil
+	return result, nil
 }
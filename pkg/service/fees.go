@@ -0,0 +1,100 @@
+package service
+
+import (
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+const day = 24 * time.Hour
+
+// Charges - из чего складывается сумма к оплате за заказ
+type Charges struct {
+	// Order - стоимость самого заказа с надбавками и скидками, включая плату за продление хранения
+	Order     float64 `json:"order"`
+	Packaging float64 `json:"packaging"`
+	Storage   float64 `json:"storage"`
+	// StorageDays - число платных суток хранения
+	StorageDays int     `json:"storage_days"`
+	Total       float64 `json:"total"`
+}
+
+// OrderListing - заказ в списке заказов клиента вместе с платой за хранение на текущий момент
+type OrderListing struct {
+	model.Order
+	// AccruedStorageFee - плата за хранение, см. StorageFee. В отличие от storage_fee, которое заполняется
+	// при выдаче, показывает и плату, накопленную хранящимся в ПВЗ заказом
+	AccruedStorageFee float64 `json:"accrued_storage_fee"`
+}
+
+// Listing - возвращает записи списка заказов с платой за хранение на текущий момент
+func (s *OrderService) Listing(orders []model.Order) []OrderListing {
+	listing := make([]OrderListing, 0, len(orders))
+	for _, order := range orders {
+		listing = append(listing, OrderListing{Order: order, AccruedStorageFee: s.StorageFee(order)})
+	}
+
+	return listing
+}
+
+// OrderCharges - возвращает разбивку суммы к оплате: для выданного заказа - на момент выдачи,
+// для хранящегося в ПВЗ - на текущий момент
+func (s *OrderService) OrderCharges(order model.Order) Charges {
+	charges := Charges{
+		Order:     order.Cost - order.PackagingCost - order.StorageFee,
+		Packaging: order.PackagingCost,
+		Storage:   s.StorageFee(order),
+	}
+	charges.StorageDays = s.storageDays(order)
+	charges.Total = charges.Order + charges.Packaging + charges.Storage
+
+	return charges
+}
+
+// StorageFee - возвращает плату за хранение: для хранящегося в ПВЗ заказа - накопленную к текущему моменту,
+// для остальных - начисленную при выдаче
+func (s *OrderService) StorageFee(order model.Order) float64 {
+	if order.State != model.StateAccepted {
+		return order.StorageFee
+	}
+
+	return s.storageFee(order, s.clock.Now())
+}
+
+// storageFee - плата за хранение заказа до момента at
+func (s *OrderService) storageFee(order model.Order, at time.Time) float64 {
+	return float64(s.paidDays(order, at)) * s.policy.StorageFee.DailyFee
+}
+
+// paidDays - число начатых суток хранения до момента at сверх бесплатного срока
+func (s *OrderService) paidDays(order model.Order, at time.Time) int {
+	if s.policy.StorageFee.DailyFee <= 0 {
+		return 0
+	}
+
+	// у заказов, принятых до учета платного хранения, нет отметки приема
+	start := order.UpdatedAt
+	if order.AcceptedAt != nil {
+		start = *order.AcceptedAt
+	}
+
+	paid := at.Sub(start) - s.policy.StorageFee.FreePeriod.Duration
+	if paid <= 0 {
+		return 0
+	}
+
+	return int((paid + day - 1) / day)
+}
+
+// storageDays - число платных суток хранения, за которые начислена плата StorageFee. Плата начисляется
+// при выдаче, поэтому у заказа, покинувшего ПВЗ без выдачи, платных суток нет
+func (s *OrderService) storageDays(order model.Order) int {
+	if order.State == model.StateAccepted {
+		return s.paidDays(order, s.clock.Now())
+	}
+	if order.DeliveredAt == nil || order.StorageFee == 0 {
+		return 0
+	}
+
+	return s.paidDays(order, *order.DeliveredAt)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+// paidStorage - 72 часа бесплатного хранения, затем 10 за сутки
+func paidStorage(p *policy.Policy) {
+	p.StorageFee = policy.StorageFeeSpec{FreePeriod: policy.Duration{Duration: 72 * time.Hour}, DailyFee: 10}
+}
+
+func TestStorageFeeDayRounding(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   time.Duration
+		wantDays int
+		wantFee  float64
+	}{
+		{name: "в пределах бесплатного срока", stored: 71 * time.Hour, wantDays: 0, wantFee: 0},
+		{name: "ровно бесплатный срок", stored: 72 * time.Hour, wantDays: 0, wantFee: 0},
+		{name: "начатые сутки считаются целыми", stored: 72*time.Hour + time.Second, wantDays: 1, wantFee: 10},
+		{name: "ровно одни платные сутки", stored: 96 * time.Hour, wantDays: 1, wantFee: 10},
+		{name: "начало вторых суток", stored: 96*time.Hour + time.Nanosecond, wantDays: 2, wantFee: 20},
+		{name: "трое платных суток", stored: 144 * time.Hour, wantDays: 3, wantFee: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestService(t, paidStorage)
+			order := acceptTestOrder(t, s, 1, 1)
+			fake.Set(testNow.Add(tt.stored))
+
+			if got := s.StorageFee(order); got != tt.wantFee {
+				t.Errorf("StorageFee = %v, ожидалось %v", got, tt.wantFee)
+			}
+			charges := s.OrderCharges(order)
+			if charges.StorageDays != tt.wantDays || charges.Storage != tt.wantFee {
+				t.Errorf("OrderCharges = %+v, ожидалось суток %d, плата %v", charges, tt.wantDays, tt.wantFee)
+			}
+
+			listing := s.Listing([]model.Order{order})
+			if listing[0].AccruedStorageFee != tt.wantFee {
+				t.Errorf("AccruedStorageFee = %v, ожидалось %v", listing[0].AccruedStorageFee, tt.wantFee)
+			}
+		})
+	}
+}
+
+func TestStorageFeeFixedAtHandout(t *testing.T) {
+	s, fake := newTestService(t, paidStorage)
+	// срок хранения больше бесплатного, чтобы заказ можно было выдать с платой
+	if err := s.AcceptOrder(1, 1, testNow.Add(240*time.Hour), 1, 100, nil, nil, AcceptOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.Set(testNow.Add(100 * time.Hour))
+	if err := s.DeliverOrder(1, 1, pickupCode(t, s, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// после выдачи плата не растет: берется начисленная при выдаче
+	fake.Set(testNow.Add(300 * time.Hour))
+	order, err := s.Repo().FindByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.StorageFee != 20 || s.StorageFee(order) != 20 || s.Listing([]model.Order{order})[0].AccruedStorageFee != 20 {
+		t.Errorf("плата за хранение %v, на текущий момент %v; ожидалось 20", order.StorageFee, s.StorageFee(order))
+	}
+	if charges := s.OrderCharges(order); charges.StorageDays != 2 {
+		t.Errorf("платных суток %d, ожидалось 2", charges.StorageDays)
+	}
+}
+
+func TestStorageDaysWithoutHandout(t *testing.T) {
+	s, fake := newTestService(t, paidStorage)
+	acceptTestOrder(t, s, 1, 1)
+
+	// заказ пролежал сверх бесплатного срока и возвращен курьеру без выдачи - плата не начислена
+	fake.Set(testNow.Add(100 * time.Hour))
+	if err := s.ReturnOrderToCourier(1); err != nil {
+		t.Fatal(err)
+	}
+	order, err := s.Repo().FindByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if charges := s.OrderCharges(order); charges.StorageDays != 0 || charges.Storage != 0 {
+		t.Errorf("OrderCharges = %+v, ожидалось без платы за хранение", charges)
+	}
+}
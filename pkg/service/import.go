@@ -0,0 +1,492 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+var (
+	ErrUnknownImportFormat = errors.New("неизвестный формат файла импорта, допустимые: json, ndjson, csv")
+	ErrInvalidColumns      = errors.New("неверное сопоставление столбцов, ожидается <поле>=<столбец>[,...]")
+	ErrInvalidDelimiter    = errors.New("разделитель CSV должен быть одним символом")
+)
+
+// ImportFormat - формат файла импорта заказов
+type ImportFormat string
+
+const (
+	// ImportJSON - JSON массив заказов
+	ImportJSON ImportFormat = "json"
+	// ImportNDJSON - по одному JSON объекту заказа на строку
+	ImportNDJSON ImportFormat = "ndjson"
+	// ImportCSV - таблица с заголовком
+	ImportCSV ImportFormat = "csv"
+)
+
+const defaultCSVDelimiter = ','
+
+// ImportOptions - параметры разбора файла импорта
+type ImportOptions struct {
+	// Format - формат файла. Если не задан, определяется по расширению и содержимому
+	Format ImportFormat
+	// Delimiter - разделитель CSV, по умолчанию запятая, а для файлов .tsv - табуляция
+	Delimiter rune
+	// Columns - заголовки столбцов CSV для полей заказа, если они отличаются от названий полей
+	Columns map[string]string
+}
+
+// csvField - поле заказа в CSV и разбор его значения
+type csvField struct {
+	name     string
+	required bool
+	set      func(data *orderFileData, value string) error
+}
+
+// csvFields - поля заказа, названия которых совпадают с JSON тегами orderFileData
+var csvFields = []csvField{
+	{name: "id", required: true, set: func(d *orderFileData, v string) (err error) {
+		d.ID, err = strconv.ParseInt(v, 10, 64)
+		return err
+	}},
+	{name: "customer_id", required: true, set: func(d *orderFileData, v string) (err error) {
+		d.CustomerID, err = strconv.ParseInt(v, 10, 64)
+		return err
+	}},
+	{name: "deadline_at", required: true, set: func(d *orderFileData, v string) error {
+		d.DeadlineAt = v
+		return nil
+	}},
+	{name: "weight", required: true, set: func(d *orderFileData, v string) (err error) {
+		d.Weight, err = parseDecimal(v)
+		return err
+	}},
+	{name: "cost", required: true, set: func(d *orderFileData, v string) (err error) {
+		d.Cost, err = parseDecimal(v)
+		return err
+	}},
+	{name: "dimensions", set: func(d *orderFileData, v string) error {
+		if v == "" {
+			return nil
+		}
+		dimensions, err := model.ParseDimensions(v)
+		if err != nil {
+			return err
+		}
+		d.Dimensions = &dimensions
+		return nil
+	}},
+	{name: "fragile", set: func(d *orderFileData, v string) (err error) {
+		if v != "" {
+			d.Fragile, err = strconv.ParseBool(v)
+		}
+		return err
+	}},
+	{name: "promo_code", set: func(d *orderFileData, v string) error {
+		d.PromoCode = v
+		return nil
+	}},
+	{name: "package_type", set: func(d *orderFileData, v string) error {
+		d.PackageType = v
+		return nil
+	}},
+	{name: "wrapper", set: func(d *orderFileData, v string) error {
+		d.Wrapper = v
+		return nil
+	}},
+	{name: "wrappers", set: func(d *orderFileData, v string) error {
+		if v != "" {
+			d.Wrappers = strings.Split(v, "+")
+		}
+		return nil
+	}},
+}
+
+// ParseImportOptions - разбирает параметры импорта, заданные строками: формат файла,
+// разделитель CSV (один символ или "tab") и сопоставление столбцов вида "id=Номер,customer_id=Клиент".
+// Пустые строки означают значения по умолчанию
+func ParseImportOptions(format, delimiter, columns string) (ImportOptions, error) {
+	var (
+		opts ImportOptions
+		err  error
+	)
+	if opts.Format, err = parseImportFormat(format); err != nil {
+		return opts, err
+	}
+	if opts.Delimiter, err = parseDelimiter(delimiter); err != nil {
+		return opts, err
+	}
+	if opts.Columns, err = parseColumns(columns); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+// parseImportFormat - разбирает название формата файла импорта. Пустая строка означает автоопределение
+func parseImportFormat(s string) (ImportFormat, error) {
+	switch format := ImportFormat(strings.ToLower(s)); format {
+	case "", ImportJSON, ImportNDJSON, ImportCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownImportFormat, s)
+	}
+}
+
+// parseDelimiter - разбирает разделитель CSV. Допускается один символ или "tab"
+func parseDelimiter(s string) (rune, error) {
+	switch s {
+	case "":
+		return 0, nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+	if size != len(s) || r == '"' || r == '\r' || r == '\n' {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDelimiter, s)
+	}
+
+	return r, nil
+}
+
+// parseColumns - разбирает сопоставление столбцов CSV
+func parseColumns(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	columns := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		name, column, ok := strings.Cut(pair, "=")
+		name, column = strings.TrimSpace(name), strings.TrimSpace(column)
+		if !ok || column == "" || !isCSVField(name) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidColumns, pair)
+		}
+		columns[name] = column
+	}
+
+	return columns, nil
+}
+
+func isCSVField(name string) bool {
+	for _, f := range csvFields {
+		if f.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// detectFormat - определяет формат файла импорта по расширению, а если оно не известно - по первому символу
+func detectFormat(filename string, r *bufio.Reader) ImportFormat {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return ImportJSON
+	case ".ndjson", ".jsonl":
+		return ImportNDJSON
+	case ".csv", ".tsv":
+		return ImportCSV
+	}
+
+	head, _ := r.Peek(sniffSize)
+	switch trimmed := bytes.TrimSpace(bytes.TrimPrefix(head, []byte(utf8BOM))); {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		return ImportJSON
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return ImportNDJSON
+	default:
+		return ImportCSV
+	}
+}
+
+// sniffSize - сколько байт начала файла просматривается при определении формата
+const sniffSize = 512
+
+const utf8BOM = "\ufeff"
+
+// maxLineSize - максимальная длина строки NDJSON
+const maxLineSize = 1024 * 1024
+
+// orderRecord - запись файла импорта с номером строки, на которой она начинается.
+// Err заполняется, если запись не удалось разобрать, но остальные записи файла читаются
+type orderRecord struct {
+	Line int
+	Data orderFileData
+	Err  error
+}
+
+// recordReader - последовательно читает записи файла импорта, не загружая файл в память целиком
+type recordReader interface {
+	// Next - возвращает следующую запись или io.EOF, если записи закончились
+	Next() (orderRecord, error)
+}
+
+// newRecordReader - создает читатель записей в формате opts.Format.
+// filename используется только для определения формата и может быть пустым
+func newRecordReader(r io.Reader, filename string, opts ImportOptions) (recordReader, error) {
+	buffered := bufio.NewReader(r)
+
+	format := opts.Format
+	if format == "" {
+		format = detectFormat(filename, buffered)
+	}
+	if opts.Delimiter == 0 && strings.EqualFold(filepath.Ext(filename), ".tsv") {
+		opts.Delimiter = '\t'
+	}
+
+	switch format {
+	case ImportJSON:
+		return newJSONRecordReader(buffered), nil
+	case ImportNDJSON:
+		return newNDJSONRecordReader(buffered), nil
+	case ImportCSV:
+		return newCSVRecordReader(buffered, opts)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownImportFormat, format)
+	}
+}
+
+// readOrders - читает все записи заказов из r
+func readOrders(r io.Reader, filename string, opts ImportOptions) ([]orderRecord, error) {
+	reader, err := newRecordReader(r, filename, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []orderRecord
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// jsonRecordReader - читает JSON массив с заказами по одному элементу.
+// Синтаксическая ошибка прерывает разбор всего файла, ошибка типов - только одной записи
+type jsonRecordReader struct {
+	decoder *json.Decoder
+	lines   *lineTracker
+	started bool
+}
+
+func newJSONRecordReader(r io.Reader) *jsonRecordReader {
+	lines := &lineTracker{r: r, line: 1}
+	return &jsonRecordReader{decoder: json.NewDecoder(lines), lines: lines}
+}
+
+// Next - возвращает следующий элемент массива
+func (j *jsonRecordReader) Next() (orderRecord, error) {
+	if !j.started {
+		if tok, err := j.decoder.Token(); err != nil || tok != json.Delim('[') {
+			return orderRecord{}, fmt.Errorf("%w: ожидается JSON массив заказов", ErrParseFile)
+		}
+		j.started = true
+	}
+
+	if !j.decoder.More() {
+		if _, err := j.decoder.Token(); err != nil {
+			return orderRecord{}, fmt.Errorf("%w: %w", ErrParseFile, err)
+		}
+		return orderRecord{}, io.EOF
+	}
+
+	var record orderRecord
+	start := j.decoder.InputOffset()
+	err := j.decoder.Decode(&record.Data)
+	record.Line = j.lines.lineAt(start)
+	if err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			return orderRecord{}, fmt.Errorf("%w: строка %d: %w", ErrParseFile, record.Line, err)
+		}
+		record.Err = fmt.Errorf("%w: %w", ErrParseFile, err)
+	}
+
+	return record, nil
+}
+
+// ndjsonRecordReader - читает заказы по одному JSON объекту на строку. Пустые строки пропускаются,
+// ошибка разбора строки относится только к ней
+type ndjsonRecordReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newNDJSONRecordReader(r io.Reader) *ndjsonRecordReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	return &ndjsonRecordReader{scanner: scanner}
+}
+
+// Next - возвращает запись из следующей непустой строки
+func (n *ndjsonRecordReader) Next() (orderRecord, error) {
+	for n.scanner.Scan() {
+		n.line++
+		text := bytes.TrimSpace(n.scanner.Bytes())
+		if n.line == 1 {
+			text = bytes.TrimPrefix(text, []byte(utf8BOM))
+		}
+		if len(text) == 0 {
+			continue
+		}
+
+		record := orderRecord{Line: n.line}
+		if err := json.Unmarshal(text, &record.Data); err != nil {
+			record.Err = fmt.Errorf("%w: %w", ErrParseFile, err)
+		}
+		return record, nil
+	}
+	if err := n.scanner.Err(); err != nil {
+		return orderRecord{}, fmt.Errorf("%w: строка %d: %w", ErrReadFile, n.line+1, err)
+	}
+
+	return orderRecord{}, io.EOF
+}
+
+// csvRecordReader - читает заказы из CSV с заголовком. Столбцы сопоставляются полям по opts.Columns
+// или по названиям полей; лишние столбцы игнорируются
+type csvRecordReader struct {
+	reader  *csv.Reader
+	indexes []int
+}
+
+func newCSVRecordReader(r io.Reader, opts ImportOptions) (*csvRecordReader, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = opts.Delimiter
+	if reader.Comma == 0 {
+		reader.Comma = defaultCSVDelimiter
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: не удалось прочитать заголовок CSV: %w", ErrParseFile, err)
+	}
+	indexes, err := csvColumnIndexes(header, opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return &csvRecordReader{reader: reader, indexes: indexes}, nil
+}
+
+// Next - возвращает запись из следующей строки CSV
+func (c *csvRecordReader) Next() (orderRecord, error) {
+	row, err := c.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return orderRecord{}, io.EOF
+	}
+	if err != nil {
+		return orderRecord{}, fmt.Errorf("%w: %w", ErrParseFile, err)
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	record := orderRecord{Line: line}
+	record.Data, record.Err = parseCSVRow(row, c.indexes)
+
+	return record, nil
+}
+
+// csvColumnIndexes - возвращает номера столбцов для полей заказа; -1 - столбец отсутствует
+func csvColumnIndexes(header []string, columns map[string]string) ([]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		positions[strings.TrimSpace(strings.TrimPrefix(name, utf8BOM))] = i
+	}
+
+	indexes := make([]int, len(csvFields))
+	for i, f := range csvFields {
+		column := f.name
+		if mapped, ok := columns[f.name]; ok {
+			column = mapped
+		}
+
+		index, ok := positions[column]
+		if !ok && f.required {
+			return nil, fmt.Errorf("%w: в заголовке CSV нет столбца %q для поля %s", ErrParseFile, column, f.name)
+		}
+		if !ok {
+			index = -1
+		}
+		indexes[i] = index
+	}
+
+	return indexes, nil
+}
+
+// parseCSVRow - разбирает строку CSV в запись заказа
+func parseCSVRow(row []string, indexes []int) (orderFileData, error) {
+	var data orderFileData
+	for i, f := range csvFields {
+		index := indexes[i]
+		if index < 0 {
+			continue
+		}
+		if index >= len(row) {
+			return data, fmt.Errorf("%w: нет значения поля %s", ErrParseFile, f.name)
+		}
+
+		if err := f.set(&data, strings.TrimSpace(row[index])); err != nil {
+			return data, fmt.Errorf("%w: поле %s: %w", ErrParseFile, f.name, err)
+		}
+	}
+
+	return data, nil
+}
+
+// parseDecimal - разбирает дробное число с точкой или запятой в качестве десятичного разделителя
+func parseDecimal(s string) (float64, error) {
+	return strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+}
+
+// lineTracker - считает строки в прочитанных данных, чтобы определить номер строки по смещению.
+// Хранит только байты, прочитанные после последнего запрошенного смещения
+type lineTracker struct {
+	r       io.Reader
+	pending []byte
+	offset  int64
+	line    int
+}
+
+// Read - читает данные из источника, запоминая их для подсчета строк
+func (t *lineTracker) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.pending = append(t.pending, p[:n]...)
+
+	return n, err
+}
+
+// lineAt - возвращает номер строки (с 1), на которой начинается значение после позиции offset.
+// Смещения должны запрашиваться по возрастанию
+func (t *lineTracker) lineAt(offset int64) int {
+	n := min(int(offset-t.offset), len(t.pending))
+	for n < len(t.pending) && bytes.IndexByte([]byte(" \t\r\n,"), t.pending[n]) >= 0 {
+		n++
+	}
+
+	t.line += bytes.Count(t.pending[:n], []byte("\n"))
+	t.pending = t.pending[n:]
+	t.offset += int64(n)
+
+	return t.line
+}
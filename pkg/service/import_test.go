@@ -0,0 +1,153 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+func TestReadOrdersCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		input    string
+		opts     ImportOptions
+		want     []orderRecord
+		wantErr  error
+	}{
+		{
+			name:     "столбцы по названиям полей в любом порядке",
+			filename: "orders.csv",
+			input: "cost,id,weight,customer_id,deadline_at,extra\n" +
+				"100,1,2.5,7,48h,x\n",
+			want: []orderRecord{
+				{Line: 2, Data: orderFileData{ID: 1, CustomerID: 7, DeadlineAt: "48h", Weight: 2.5, Cost: 100}},
+			},
+		},
+		{
+			name:     "сопоставление столбцов, разделитель и десятичная запятая",
+			filename: "manifest.txt",
+			input: "\ufeffНомер;Клиент;Срок;Вес;Цена;Упаковка;Обертки;Габариты\n" +
+				"1;1;2030-02-20T15:04:05;5,5;100;box;film+bubble;50x40x30\n",
+			opts: ImportOptions{
+				Format:    ImportCSV,
+				Delimiter: ';',
+				Columns: map[string]string{
+					"id": "Номер", "customer_id": "Клиент", "deadline_at": "Срок", "weight": "Вес",
+					"cost": "Цена", "package_type": "Упаковка", "wrappers": "Обертки", "dimensions": "Габариты",
+				},
+			},
+			want: []orderRecord{
+				{Line: 2, Data: orderFileData{
+					ID: 1, CustomerID: 1, DeadlineAt: "2030-02-20T15:04:05", Weight: 5.5, Cost: 100,
+					PackageType: "box", Wrappers: []string{"film", "bubble"},
+					Dimensions: &model.Dimensions{Length: 50, Width: 40, Height: 30},
+				}},
+			},
+		},
+		{
+			name:     "табуляция для .tsv",
+			filename: "orders.tsv",
+			input:    "id\tcustomer_id\tdeadline_at\tweight\tcost\tfragile\tpromo_code\n1\t1\t48h\t1\t50\ttrue\tSPRING10\n",
+			want: []orderRecord{
+				{Line: 2, Data: orderFileData{ID: 1, CustomerID: 1, DeadlineAt: "48h", Weight: 1, Cost: 50, Fragile: true, PromoCode: "SPRING10"}},
+			},
+		},
+		{
+			name:     "нет обязательного столбца",
+			filename: "orders.csv",
+			input:    "id,customer_id,deadline_at,weight\n1,1,48h,1\n",
+			wantErr:  ErrParseFile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readOrders(strings.NewReader(tt.input), tt.filename, tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ошибка = %v, ожидалась %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readOrders = %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadOrdersCSVRecordErrors(t *testing.T) {
+	input := "id,customer_id,deadline_at,weight,cost\n" +
+		"1,1,48h,1,100\n" +
+		"x,1,48h,1,100\n" +
+		"3,1,48h\n"
+
+	records, err := readOrders(strings.NewReader(input), "orders.csv", ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("записей = %d, ожидалось 3", len(records))
+	}
+
+	// ошибка в строке отклоняет только эту запись
+	wantErr := []bool{false, true, true}
+	for i, record := range records {
+		if got := record.Err != nil; got != wantErr[i] {
+			t.Errorf("строка %d: ошибка = %v", record.Line, record.Err)
+		}
+		if record.Err != nil && !errors.Is(record.Err, ErrParseFile) {
+			t.Errorf("строка %d: ошибка = %v, ожидалась %v", record.Line, record.Err, ErrParseFile)
+		}
+	}
+}
+
+func TestParseImportOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		delimiter string
+		columns   string
+		want      ImportOptions
+		wantErr   error
+	}{
+		{name: "по умолчанию", want: ImportOptions{}},
+		{
+			name:      "все параметры",
+			format:    "CSV",
+			delimiter: "tab",
+			columns:   "id = Номер, cost=Цена",
+			want:      ImportOptions{Format: ImportCSV, Delimiter: '\t', Columns: map[string]string{"id": "Номер", "cost": "Цена"}},
+		},
+		{name: "неизвестный формат", format: "xml", wantErr: ErrUnknownImportFormat},
+		{name: "длинный разделитель", delimiter: ";;", wantErr: ErrInvalidDelimiter},
+		{name: "кавычка как разделитель", delimiter: `"`, wantErr: ErrInvalidDelimiter},
+		{name: "неизвестное поле", columns: "price=Цена", wantErr: ErrInvalidColumns},
+		{name: "пустой столбец", columns: "id=", wantErr: ErrInvalidColumns},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseImportOptions(tt.format, tt.delimiter, tt.columns)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ошибка = %v, ожидалась %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseImportOptions = %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
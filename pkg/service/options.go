@@ -0,0 +1,64 @@
+package service
+
+import (
+	"gitlab.ozon.dev/gojhw1/pkg/audit"
+	"gitlab.ozon.dev/gojhw1/pkg/clock"
+	"gitlab.ozon.dev/gojhw1/pkg/notify"
+	"gitlab.ozon.dev/gojhw1/pkg/packaging"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+	"gitlab.ozon.dev/gojhw1/pkg/pricing"
+)
+
+// Option - настройка сервиса заказов
+type Option func(s *OrderService)
+
+// WithClock - задает часы, по которым сервис определяет текущее время
+func WithClock(c clock.Clock) Option {
+	return func(s *OrderService) {
+		s.clock.Set(c)
+	}
+}
+
+// WithPolicy - задает бизнес-правила: срок возврата, тарифы и ограничения упаковки
+func WithPolicy(p *policy.Policy) Option {
+	return func(s *OrderService) {
+		s.policy = p
+	}
+}
+
+// WithEventLog - задает журнал, в который записываются события смены состояния заказов
+func WithEventLog(l audit.Log) Option {
+	return func(s *OrderService) {
+		s.events = l
+	}
+}
+
+// WithActor - задает, от чьего имени записываются события заказов
+func WithActor(actor string) Option {
+	return func(s *OrderService) {
+		if actor != "" {
+			s.actor = actor
+		}
+	}
+}
+
+// WithCodeOutbox - задает выгрузку, в которую передаются коды выдачи для уведомления клиентов
+func WithCodeOutbox(o notify.Outbox) Option {
+	return func(s *OrderService) {
+		s.codes = o
+	}
+}
+
+// WithPackaging - задает реестр типов упаковки и оберток, доступных при приеме заказов
+func WithPackaging(r *packaging.Registry) Option {
+	return func(s *OrderService) {
+		s.packaging = r
+	}
+}
+
+// WithPricing - задает правила расчета стоимости заказа вместо правил из политики
+func WithPricing(p *pricing.Pipeline) Option {
+	return func(s *OrderService) {
+		s.pricing = p
+	}
+}
@@ -2,87 +2,71 @@ package service
 
 import (
 	"errors"
-)
 
-var (
-	ErrPackageWeightExceeded = errors.New("превышен максимальный вес для данного типа упаковки")
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/packaging"
 )
 
-const (
-	bagCost  = 5.0
-	boxCost  = 20.0
-	filmCost = 1.0
-)
-
-const (
-	bagMaxWeight = 10.0
-	boxMaxWeight = 30.0
+var (
+	ErrPackageWeightExceeded     = errors.New("превышен максимальный вес для данного типа упаковки")
+	ErrPackageDimensionsExceeded = errors.New("габариты заказа превышают габариты данного типа упаковки")
+	ErrDimensionsRequired        = errors.New("для данного типа упаковки нужно указать габариты заказа")
 )
 
 type packager interface {
 	validateWeight(weight float64) error
-	getAdditionalCost() float64
+	validateDimensions(dimensions *model.Dimensions) error
+	// chargeableWeight - оплачиваемый вес: фактический или объемный, если упаковка его учитывает
+	chargeableWeight(weight float64, dimensions *model.Dimensions) float64
+	// getAdditionalCost - стоимость упаковки заказа с оплачиваемым весом weight
+	getAdditionalCost(weight float64) float64
 	getDescription() string
 }
 
+// basicPackager - упаковщик для типа упаковки из реестра
 type basicPackager struct {
-	description string
-	maxWeight   float64
-	cost        float64
+	spec packaging.Package
+}
+
+func newBasicPackager(pkg packaging.Package) *basicPackager {
+	return &basicPackager{spec: pkg}
 }
 
 func (p *basicPackager) validateWeight(weight float64) error {
-	if p.maxWeight > 0 && weight > p.maxWeight {
+	if p.spec.MaxWeight > 0 && weight > p.spec.MaxWeight {
 		return ErrPackageWeightExceeded
 	}
 	return nil
 }
 
-func (p *basicPackager) getAdditionalCost() float64 {
-	return p.cost
-}
-
-func (p *basicPackager) getDescription() string {
-	return p.description
-}
-
-type bagPackager struct {
-	basicPackager
-}
-
-func newBagPackager() *bagPackager {
-	return &bagPackager{
-		basicPackager{
-			description: "bag",
-			maxWeight:   bagMaxWeight,
-			cost:        bagCost,
-		},
+// validateDimensions - проверяет, что заказ помещается в упаковку. Габариты можно не указывать,
+// если упаковка не считает объемный вес
+func (p *basicPackager) validateDimensions(dimensions *model.Dimensions) error {
+	if dimensions == nil {
+		if p.spec.VolumetricDivisor > 0 {
+			return ErrDimensionsRequired
+		}
+		return nil
 	}
-}
 
-type boxPackager struct {
-	basicPackager
+	if p.spec.MaxDimensions != nil && !dimensions.FitsIn(*p.spec.MaxDimensions) {
+		return ErrPackageDimensionsExceeded
+	}
+	return nil
 }
 
-func newBoxPackager() *boxPackager {
-	return &boxPackager{
-		basicPackager{
-			description: "box",
-			maxWeight:   boxMaxWeight,
-			cost:        boxCost,
-		},
+func (p *basicPackager) chargeableWeight(weight float64, dimensions *model.Dimensions) float64 {
+	if dimensions == nil {
+		return weight
 	}
+
+	return max(weight, p.spec.VolumetricWeight(*dimensions))
 }
 
-type FilmPackager struct {
-	basicPackager
+func (p *basicPackager) getAdditionalCost(weight float64) float64 {
+	return p.spec.Cost + p.spec.CostPerKg*weight
 }
 
-func newFilmPackager() *FilmPackager {
-	return &FilmPackager{
-		basicPackager{
-			description: "film",
-			cost:        filmCost,
-		},
-	}
+func (p *basicPackager) getDescription() string {
+	return string(p.spec.Name)
 }
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/packaging"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+const testParcel model.PackageType = "parcel"
+
+// parcelSpec - посылка до 60x40x40 см за 10 и 1 за кг оплачиваемого веса, объемный вес - объем / 5000
+var parcelSpec = policy.PackageSpec{
+	Cost:              10,
+	CostPerKg:         1,
+	MaxDimensions:     &model.Dimensions{Length: 60, Width: 40, Height: 40},
+	VolumetricDivisor: 5000,
+}
+
+func TestBasicPackagerDimensions(t *testing.T) {
+	parcel := newBasicPackager(packaging.Package{
+		Name: testParcel, MaxDimensions: parcelSpec.MaxDimensions, VolumetricDivisor: parcelSpec.VolumetricDivisor,
+	})
+	bag := newBasicPackager(packaging.Package{Name: model.PackageBag})
+
+	tests := []struct {
+		name       string
+		packager   *basicPackager
+		dimensions *model.Dimensions
+		wantErr    error
+	}{
+		{name: "габариты не указаны", packager: parcel, wantErr: ErrDimensionsRequired},
+		{name: "габариты не нужны без объемного веса", packager: bag},
+		{name: "помещается с поворотом", packager: parcel, dimensions: &model.Dimensions{Length: 40, Width: 60, Height: 30}},
+		{name: "слишком длинный", packager: parcel, dimensions: &model.Dimensions{Length: 70, Width: 30, Height: 30}, wantErr: ErrPackageDimensionsExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.packager.validateDimensions(tt.dimensions); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ошибка = %v, ожидалась %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPackagingCostVolumetric(t *testing.T) {
+	s, _ := newTestService(t, func(p *policy.Policy) {
+		p.Packages[testParcel] = parcelSpec
+	})
+
+	tests := []struct {
+		name       string
+		weight     float64
+		dimensions *model.Dimensions
+		// wantCost - 10 + оплачиваемый вес
+		wantCost float64
+	}{
+		{name: "объемный вес больше фактического", weight: 2, dimensions: &model.Dimensions{Length: 50, Width: 40, Height: 30}, wantCost: 22},
+		{name: "фактический вес больше объемного", weight: 20, dimensions: &model.Dimensions{Length: 50, Width: 40, Height: 30}, wantCost: 30},
+	}
+
+	parcel := testParcel
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := int64(i + 1)
+			err := s.AcceptOrder(id, 1, s.Now().Add(48*time.Hour), tt.weight, 100, &parcel, nil, AcceptOptions{Dimensions: tt.dimensions})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			order, err := s.Repo().FindByID(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order.PackagingCost != tt.wantCost {
+				t.Errorf("стоимость упаковки %v, ожидалось %v", order.PackagingCost, tt.wantCost)
+			}
+		})
+	}
+}
+
+func TestAcceptOrderUnregisteredPackage(t *testing.T) {
+	s, _ := newTestService(t, nil)
+
+	parcel := testParcel
+	err := s.AcceptOrder(1, 1, s.Now().Add(48*time.Hour), 1, 100, &parcel, nil, AcceptOptions{})
+	if !errors.Is(err, ErrUnknownPackageType) {
+		t.Errorf("ошибка = %v, ожидалась %v", err, ErrUnknownPackageType)
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/repository"
+)
+
+var (
+	ErrUnknownPoint = errors.New("неизвестный пункт выдачи")
+)
+
+// PointSummary - пункт выдачи и его текущая загрузка
+type PointSummary struct {
+	model.PickupPoint
+	Usage Utilization `json:"usage"`
+}
+
+// ForPoint - возвращает копию сервиса, работающую только с заказами пункта выдачи point.
+// Заказы без пункта, принятые до настройки нескольких пунктов, относятся к первому пункту из политики.
+// Пустой point - все заказы без ограничения по пунктам (если пункты в политике не заданы)
+func (s *OrderService) ForPoint(point string) (*OrderService, error) {
+	if _, ok := s.policy.Point(point); point != "" && !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPoint, point)
+	}
+
+	scoped := *s
+	scoped.point = point
+	scoped.repo = scoped.scope(s.store)
+
+	return &scoped, nil
+}
+
+// scope - ограничивает общий репозиторий заказами выбранного пункта выдачи
+func (s *OrderService) scope(store repository.Repository) repository.Repository {
+	if s.point == "" {
+		return store
+	}
+
+	return repository.NewPointRepository(store, s.point, s.point == s.policy.Points[0].ID)
+}
+
+// Point - возвращает ID пункта выдачи, с которым работает сервис. Пустая строка - пункт не выбран
+func (s *OrderService) Point() string {
+	return s.point
+}
+
+// Points - возвращает пункты выдачи из политики с их текущей загрузкой
+func (s *OrderService) Points() ([]PointSummary, error) {
+	summaries := make([]PointSummary, 0, len(s.policy.Points))
+	for _, point := range s.policy.Points {
+		scoped, err := s.ForPoint(point.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		usage, err := scoped.Utilization()
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, PointSummary{PickupPoint: point, Usage: usage})
+	}
+
+	return summaries, nil
+}
+
+// ClearOrders - удаляет заказы выбранного пункта выдачи, а если пункт не выбран - все заказы
+func (s *OrderService) ClearOrders() error {
+	if s.point == "" {
+		return s.repo.SetAll(make(map[int64]model.Order))
+	}
+
+	return s.repo.WithTx(func(tx repository.Repository) error {
+		orders, err := tx.List()
+		if err != nil {
+			return err
+		}
+		for _, order := range orders {
+			if err = tx.Delete(order.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+	"gitlab.ozon.dev/gojhw1/pkg/repository"
+)
+
+// twoPoints - два пункта выдачи, msk-1 - пункт по умолчанию
+func twoPoints(p *policy.Policy) {
+	p.Points = []model.PickupPoint{{ID: "msk-1"}, {ID: "msk-2"}}
+}
+
+// forPoint - возвращает сервис пункта выдачи point
+func forPoint(t *testing.T, s *OrderService, point string) *OrderService {
+	t.Helper()
+
+	scoped, err := s.ForPoint(point)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return scoped
+}
+
+func TestForPointUnknown(t *testing.T) {
+	s, _ := newTestService(t, twoPoints)
+	if _, err := s.ForPoint("spb-1"); !errors.Is(err, ErrUnknownPoint) {
+		t.Errorf("ошибка = %v, ожидалась %v", err, ErrUnknownPoint)
+	}
+}
+
+func TestPointScoping(t *testing.T) {
+	s, _ := newTestService(t, twoPoints)
+	// заказ 1 принят до настройки нескольких пунктов
+	acceptTestOrder(t, s, 1, 1)
+	acceptTestOrder(t, forPoint(t, s, "msk-1"), 2, 1)
+	acceptTestOrder(t, forPoint(t, s, "msk-2"), 3, 1)
+
+	tests := []struct {
+		point      string
+		wantOrders int
+		foreign    int64
+	}{
+		{point: "msk-1", wantOrders: 2, foreign: 3},
+		{point: "msk-2", wantOrders: 1, foreign: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.point, func(t *testing.T) {
+			scoped := forPoint(t, s, tt.point)
+
+			orders, err := scoped.ListOrders(1, 0, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(orders) != tt.wantOrders {
+				t.Errorf("заказов клиента %d, ожидалось %d", len(orders), tt.wantOrders)
+			}
+
+			// заказ другого пункта не находится
+			if err = scoped.ReturnOrderToCourier(tt.foreign); !errors.Is(err, repository.ErrOrderNotFound) {
+				t.Errorf("возврат заказа другого пункта: ошибка = %v, ожидалась %v", err, repository.ErrOrderNotFound)
+			}
+		})
+	}
+
+	summaries, err := s.Points()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int{2, 1} {
+		if summaries[i].Usage.Orders != want {
+			t.Errorf("загрузка %s: %d заказов, ожидалось %d", summaries[i].ID, summaries[i].Usage.Orders, want)
+		}
+	}
+}
+
+func TestClearOrdersInPoint(t *testing.T) {
+	s, _ := newTestService(t, twoPoints)
+	acceptTestOrder(t, forPoint(t, s, "msk-1"), 1, 1)
+	acceptTestOrder(t, forPoint(t, s, "msk-2"), 2, 1)
+
+	if err := forPoint(t, s, "msk-2").ClearOrders(); err != nil {
+		t.Fatal(err)
+	}
+
+	// удалены только заказы выбранного пункта
+	all, err := s.Repo().GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := all[1]; len(all) != 1 || !ok {
+		t.Errorf("после очистки msk-2 остались заказы %v, ожидался только 1", all)
+	}
+}
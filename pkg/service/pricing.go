@@ -0,0 +1,122 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/pricing"
+)
+
+// Строки разбивки стоимости, добавляемые после приема заказа
+const (
+	ruleExtension = "extension"
+	ruleStorage   = "storage"
+)
+
+// AcceptOptions - необязательные параметры принимаемого заказа
+type AcceptOptions struct {
+	// Dimensions - габариты заказа, nil - не указаны
+	Dimensions *model.Dimensions
+	Fragile    bool
+	PromoCode  string
+}
+
+// PriceBreakdown - из чего сложилась стоимость заказа
+type PriceBreakdown struct {
+	OrderID int64             `json:"order_id"`
+	Lines   []model.PriceLine `json:"lines"`
+	Total   float64           `json:"total"`
+}
+
+// PriceBreakdown - возвращает разбивку стоимости заказа. Для хранящегося в ПВЗ заказа
+// в нее входит плата за хранение, накопленная к текущему моменту
+func (s *OrderService) PriceBreakdown(id int64) (PriceBreakdown, error) {
+	order, err := s.repo.FindByID(id)
+	if err != nil {
+		return PriceBreakdown{}, err
+	}
+
+	breakdown := PriceBreakdown{OrderID: id, Lines: slices.Clone(order.PriceBreakdown), Total: order.Cost}
+	if len(breakdown.Lines) == 0 {
+		breakdown.Lines = legacyBreakdown(order)
+	}
+	if fee := s.StorageFee(order); order.State == model.StateAccepted && fee > 0 {
+		breakdown.Lines = append(breakdown.Lines, model.PriceLine{Rule: ruleStorage, Description: "Платное хранение на текущий момент", Amount: fee})
+		breakdown.Total = pricing.Round(breakdown.Total + fee)
+	}
+
+	return breakdown, nil
+}
+
+// priceOrder - рассчитывает стоимость принимаемого заказа по правилам и записывает ее разбивку в заказ
+func (s *OrderService) priceOrder(order *model.Order, cost float64) error {
+	customerOrders, err := s.deliveredOrders(order.CustomerID)
+	if err != nil {
+		return err
+	}
+
+	quote, err := s.pricing.Price(pricing.Input{
+		Cost:           cost,
+		PackagingCost:  order.PackagingCost,
+		Weight:         order.Weight,
+		Fragile:        order.Fragile,
+		PromoCode:      order.PromoCode,
+		CustomerOrders: customerOrders,
+	})
+	if err != nil {
+		return err
+	}
+	order.Cost = quote.Total
+	order.PriceBreakdown = quote.Lines
+
+	return nil
+}
+
+// deliveredOrders - число заказов, выданных клиенту во всех пунктах выдачи.
+// Считается, только если действует скидка постоянного клиента
+func (s *OrderService) deliveredOrders(customerID int64) (int, error) {
+	if s.policy.Pricing.Loyalty.Percent <= 0 {
+		return 0, nil
+	}
+
+	orders, err := s.store.ListByCustomer(customerID)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения заказов клиента %d: %w", customerID, err)
+	}
+
+	count := 0
+	for _, order := range orders {
+		if order.State == model.StateDelivered {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// addCharge - добавляет к стоимости принятого заказа начисление: плату за продление или хранение.
+// У заказов, принятых до расчета стоимости по правилам, разбивка не ведется
+func addCharge(order *model.Order, rule, description string, amount float64) {
+	if amount == 0 {
+		return
+	}
+
+	order.Cost = pricing.Round(order.Cost + amount)
+	if len(order.PriceBreakdown) > 0 {
+		order.PriceBreakdown = append(order.PriceBreakdown, model.PriceLine{Rule: rule, Description: description, Amount: amount})
+	}
+}
+
+// legacyBreakdown - восстанавливает разбивку стоимости заказа, принятого до расчета по правилам
+func legacyBreakdown(order model.Order) []model.PriceLine {
+	lines := []model.PriceLine{{Rule: pricing.RuleBase, Description: "Стоимость заказа", Amount: order.Cost - order.PackagingCost - order.StorageFee}}
+	if order.PackagingCost > 0 {
+		lines = append(lines, model.PriceLine{Rule: "packaging", Description: "Упаковка", Amount: order.PackagingCost})
+	}
+	if order.StorageFee > 0 {
+		lines = append(lines, model.PriceLine{Rule: ruleStorage, Description: "Платное хранение", Amount: order.StorageFee})
+	}
+
+	return lines
+}
@@ -3,44 +3,88 @@ package service
 import (
 	"errors"
 	"fmt"
+	"log"
 	"sort"
 	"time"
 
+	"gitlab.ozon.dev/gojhw1/pkg/audit"
+	"gitlab.ozon.dev/gojhw1/pkg/clock"
 	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/notify"
+	"gitlab.ozon.dev/gojhw1/pkg/packaging"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+	"gitlab.ozon.dev/gojhw1/pkg/pricing"
 	"gitlab.ozon.dev/gojhw1/pkg/repository"
 )
 
 var (
-	ErrStorageDeadlinePassed = errors.New("срок хранения в прошлом")
-	ErrOrderExists           = errors.New("заказ уже существует")
-	ErrDeadlineNotExpired    = errors.New("срок хранения заказа еще не истек")
-	ErrOrderAlreadyDelivered = errors.New("заказ уже доставлен клиенту, возврат невозможен")
-	ErrWrongCustomer         = errors.New("заказ принадлежит другому клиенту")
-	ErrWrongState            = errors.New("заказ нельзя выдать – неверное состояние")
-	ErrStorageExpired        = errors.New("срок хранения заказа истек")
-	ErrNotDelivered          = errors.New("заказ не был выдан, возврат невозможен")
-	ErrReturnExpired         = errors.New("срок возврата заказа истек")
-	ErrOpenFile              = errors.New("ошибка при открытии файла принятия заказов")
-	ErrReadFile              = errors.New("ошибка при чтении файла принятия заказов")
-	ErrParseFile             = errors.New("ошибка при разборе файла принятия заказов")
-	ErrInvalidDateFormat     = errors.New("неверный формат даты или длительности")
-	ErrNegativeWeight        = errors.New("вес должен быть положительным числом")
-	ErrNegativeCost          = errors.New("стоимость должна быть положительным числом")
+	ErrStorageDeadlinePassed    = errors.New("срок хранения в прошлом")
+	ErrOrderExists              = errors.New("заказ уже существует")
+	ErrDeadlineNotExpired       = errors.New("срок хранения заказа еще не истек")
+	ErrOrderAlreadyDelivered    = errors.New("заказ уже доставлен клиенту, возврат невозможен")
+	ErrWrongCustomer            = errors.New("заказ принадлежит другому клиенту")
+	ErrWrongState               = errors.New("заказ нельзя выдать – неверное состояние")
+	ErrStorageExpired           = errors.New("срок хранения заказа истек")
+	ErrNotDelivered             = errors.New("заказ не был выдан, возврат невозможен")
+	ErrReturnExpired            = errors.New("срок возврата заказа истек")
+	ErrAlreadyReturnedToCourier = errors.New("заказ уже возвращен курьеру")
+	ErrOpenFile                 = errors.New("ошибка при открытии файла принятия заказов")
+	ErrReadFile                 = errors.New("ошибка при чтении файла принятия заказов")
+	ErrParseFile                = errors.New("ошибка при разборе файла принятия заказов")
+	ErrInvalidDateFormat        = errors.New("неверный формат даты или длительности")
+	ErrNegativeWeight           = errors.New("вес должен быть положительным числом")
+	ErrNegativeCost             = errors.New("стоимость должна быть положительным числом")
 )
 
-const ReturnedAt = 48 * time.Hour
 const timeLayout = "2006-01-02T15:04:05"
 
 // OrderService - структура сервиса для работы с заказами
 type OrderService struct {
 	repo repository.Repository
+	// store - общий репозиторий всех пунктов выдачи; repo - его часть, доступная выбранному пункту
+	store repository.Repository
+	point string
+	// clock - общие для всех копий сервиса часы, см. SetClock
+	clock  *clock.Settable
+	policy *policy.Policy
+	events audit.Log
+	actor  string
+
+	// packaging - реестр типов упаковки и оберток
+	packaging *packaging.Registry
+	// pricing - правила расчета стоимости заказа
+	pricing *pricing.Pipeline
+
+	codes notify.Outbox
+
+	// pending - события и коды выдачи, отложенные до завершения транзакции
+	pending *pendingWrites
 }
 
-// NewOrderService - создаёт новый сервис с переданным репозиторием
-func NewOrderService(repo repository.Repository) *OrderService {
-	return &OrderService{
-		repo: repo,
+// NewOrderService - создаёт новый сервис с переданным репозиторием.
+// По умолчанию используются системные часы, политика policy.Default, журнал событий и выгрузка кодов в памяти.
+// Если реестр упаковки и правила расчета стоимости не заданы, они берутся из политики
+func NewOrderService(repo repository.Repository, opts ...Option) *OrderService {
+	s := &OrderService{
+		repo:   repo,
+		store:  repo,
+		clock:  clock.NewSettable(clock.Real{}),
+		policy: policy.Default(),
+		events: audit.NewMemoryLog(),
+		codes:  notify.NewMemoryOutbox(),
+		actor:  defaultActor,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.packaging == nil {
+		s.packaging = packaging.FromPolicy(s.policy)
+	}
+	if s.pricing == nil {
+		s.pricing = pricing.New(s.policy.Pricing)
+	}
+
+	return s
 }
 
 // Repo - возвращает репозиторий, связанный с сервисом
@@ -48,72 +92,216 @@ func (s *OrderService) Repo() repository.Repository {
 	return s.repo
 }
 
-// AcceptOrder - принимает заказ, если он корректен и не просрочен
-func (s *OrderService) AcceptOrder(id, customerID int64, deadline time.Time, weight, cost float64, packageType *model.PackageType, wrapper *model.WrapperType) error {
-	now := time.Now()
+// Clock - возвращает часы, по которым работает сервис
+func (s *OrderService) Clock() clock.Clock {
+	return s.clock.Source()
+}
+
+// Policy - возвращает действующие бизнес-правила
+func (s *OrderService) Policy() *policy.Policy {
+	return s.policy
+}
+
+// Packaging - возвращает реестр типов упаковки и оберток
+func (s *OrderService) Packaging() *packaging.Registry {
+	return s.packaging
+}
+
+// SetClock - заменяет часы сервиса. Часы общие для сервиса и его копий для пунктов выдачи,
+// операторов и транзакций, поэтому новое время видно им всем
+func (s *OrderService) SetClock(c clock.Clock) {
+	s.clock.Set(c)
+}
+
+// Now - возвращает текущее время по часам сервиса
+func (s *OrderService) Now() time.Time {
+	return s.clock.Now()
+}
+
+// AcceptOrder - принимает заказ, если он корректен и не просрочен.
+// Стоимость заказа рассчитывается по правилам политики, ее разбивка сохраняется в заказе
+func (s *OrderService) AcceptOrder(id, customerID int64, deadline time.Time, weight, cost float64, packageType *model.PackageType, wrappers []model.WrapperType, opts AcceptOptions) error {
+	return s.acceptOrder(commandAcceptOrder, id, customerID, deadline, weight, cost, packageType, wrappers, opts)
+}
+
+func (s *OrderService) acceptOrder(command string, id, customerID int64, deadline time.Time, weight, cost float64, packageType *model.PackageType, wrappers []model.WrapperType, opts AcceptOptions) error {
+	order, err := s.buildOrder(id, customerID, deadline, weight, cost, packageType, wrappers, opts, s.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	return s.addOrder(order, command)
+}
+
+// buildOrder - проверяет параметры заказа и возвращает принятый заказ, не сохраняя его
+func (s *OrderService) buildOrder(id, customerID int64, deadline time.Time, weight, cost float64, packageType *model.PackageType, wrappers []model.WrapperType, opts AcceptOptions, now time.Time) (model.Order, error) {
 	if now.After(deadline) {
-		return fmt.Errorf("%w: %v \n Текущая дата: %v", ErrStorageDeadlinePassed, deadline, now)
+		return model.Order{}, fmt.Errorf("%w: %v \n Текущая дата: %v", ErrStorageDeadlinePassed, deadline, now)
 	}
-	if order, _ := s.repo.FindByID(id); order.ID == id {
-		return fmt.Errorf("%w: Id %d", ErrOrderExists, id)
+	if err := s.checkNewOrder(id); err != nil {
+		return model.Order{}, err
 	}
 	if weight <= 0 {
-		return fmt.Errorf("%w: %v", ErrNegativeWeight, weight)
+		return model.Order{}, fmt.Errorf("%w: %v", ErrNegativeWeight, weight)
 	}
 	if cost <= 0 {
-		return fmt.Errorf("%w: %v", ErrNegativeCost, cost)
+		return model.Order{}, fmt.Errorf("%w: %v", ErrNegativeCost, cost)
+	}
+
+	packageType, wrappers, packagingCost, err := s.orderPackaging(packageType, wrappers, weight, opts.Dimensions)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	order := model.Order{
+		ID:            id,
+		CustomerID:    customerID,
+		DeadlineAt:    deadline,
+		Weight:        weight,
+		Dimensions:    opts.Dimensions,
+		PackagingCost: packagingCost,
+		Fragile:       opts.Fragile,
+		PromoCode:     opts.PromoCode,
+		PackageType:   packageType,
+		Wrappers:      wrappers,
+	}
+	if err = s.priceOrder(&order, cost); err != nil {
+		return model.Order{}, err
+	}
+	if err := order.TransitionTo(model.StateAccepted, now); err != nil {
+		return model.Order{}, err
+	}
+
+	return order, nil
+}
+
+// orderPackaging - проверяет габариты заказа, подбирает упаковку, если указан тип auto,
+// и возвращает упаковку с обертками и ее стоимость
+func (s *OrderService) orderPackaging(packageType *model.PackageType, wrappers []model.WrapperType, weight float64, dimensions *model.Dimensions) (*model.PackageType, []model.WrapperType, float64, error) {
+	if dimensions != nil && !dimensions.Valid() {
+		return nil, nil, 0, fmt.Errorf("%w: %s", model.ErrInvalidDimensions, dimensions)
+	}
+
+	if packageType != nil && *packageType == model.PackageAuto {
+		var err error
+		if packageType, wrappers, err = s.autoPackaging(wrappers, weight, dimensions); err != nil {
+			return nil, nil, 0, err
+		}
 	}
 
-	finalCost := cost
+	cost, err := s.packagingCost(packageType, wrappers, weight, dimensions)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	return packageType, wrappers, cost, nil
+}
+
+// packagingCost - проверяет, что заказ подходит для упаковки с обертками, и возвращает ее стоимость.
+// Стоимость считается по оплачиваемому весу - фактическому или объемному, если упаковка его учитывает
+func (s *OrderService) packagingCost(packageType *model.PackageType, wrappers []model.WrapperType, weight float64, dimensions *model.Dimensions) (float64, error) {
+	if packageType == nil {
+		return 0, nil
+	}
+
+	factory := newPackagerFactory(s.packaging)
+	packager, err := factory.createPackager(packageType, wrappers)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка создания упаковщика: %w", err)
+	}
+
+	if err = packager.validateWeight(weight); err != nil {
+		return 0, fmt.Errorf("ошибка проверки веса для упаковки %s: %w", *packageType, err)
+	}
+	if err = packager.validateDimensions(dimensions); err != nil {
+		return 0, fmt.Errorf("ошибка проверки габаритов для упаковки %s: %w", *packageType, err)
+	}
+
+	return packager.getAdditionalCost(packager.chargeableWeight(weight, dimensions)), nil
+}
+
+// addOrder - проверяет вместимость ПВЗ, назначает принятому заказу ячейку и код выдачи,
+// сохраняет его, записывает событие приема и передает код в выгрузку. Проверки и сохранение
+// выполняются в одной транзакции, чтобы параллельный прием не занял ту же ячейку или место
+func (s *OrderService) addOrder(order model.Order, command string) error {
+	return s.withTx(func(tx *OrderService) error {
+		if err := tx.checkNewOrder(order.ID); err != nil {
+			return err
+		}
+		if err := tx.checkCapacity(order); err != nil {
+			return err
+		}
 
-	if packageType != nil {
-		factory := newPackagerFactory()
-		packager, err := factory.createPackager(packageType, wrapper)
+		cell, err := tx.assignCell(order)
 		if err != nil {
-			return fmt.Errorf("ошибка создания упаковщика: %w", err)
+			return err
 		}
+		order.Cell = cell
 
-		if err = packager.validateWeight(weight); err != nil {
-			return fmt.Errorf("ошибка проверки веса для упаковки %s: %w", *packageType, err)
+		notice, err := tx.issuePickupCode(&order)
+		if err != nil {
+			return err
 		}
 
-		finalCost += packager.getAdditionalCost()
-	}
+		if err = tx.repo.Add(order); err != nil {
+			return err
+		}
 
-	order := model.Order{
-		ID:          id,
-		CustomerID:  customerID,
-		DeadlineAt:  deadline,
-		State:       model.StateAccepted,
-		UpdatedAt:   now,
-		Weight:      weight,
-		Cost:        finalCost,
-		PackageType: packageType,
-		Wrapper:     wrapper,
+		if err = tx.recordEvent(order.ID, model.StateNew, model.StateAccepted, command, withCell("принят от курьера", cell)); err != nil {
+			return err
+		}
+
+		return tx.sendCode(notice)
+	})
+}
+
+// checkNewOrder - проверяет, что заказа с таким номером еще нет
+func (s *OrderService) checkNewOrder(id int64) error {
+	if order, _ := s.repo.FindByID(id); order.ID == id {
+		return fmt.Errorf("%w: Id %d", ErrOrderExists, id)
 	}
 
-	return s.repo.Add(order)
+	return nil
 }
 
-// ReturnOrderToCourier - возвращает заказ курьеру, если условия возврата соблюдены
+// ReturnOrderToCourier - возвращает заказ курьеру, если условия возврата соблюдены.
+// Заказ остается в репозитории в конечном состоянии returned_to_courier
 func (s *OrderService) ReturnOrderToCourier(id int64) error {
-	now := time.Now()
+	return s.withTx(func(tx *OrderService) error {
+		return tx.returnOrderToCourier(id)
+	})
+}
+
+// returnOrderToCourier - возвращает заказ курьеру. Вызывается в транзакции, чтобы условия возврата
+// проверялись по актуальному состоянию заказа
+func (s *OrderService) returnOrderToCourier(id int64) error {
+	now := s.clock.Now()
 	order, err := s.repo.FindByID(id)
 	if err != nil {
 		return fmt.Errorf("ошибка при возврата заказа курьеру Id %d: %w", id, err)
 	}
-	if now.Before(order.DeadlineAt) && order.State != model.StateReturned {
+	if order.State == model.StateAccepted && now.Before(order.DeadlineAt) {
 		return fmt.Errorf("%w: %v\n текущая дата: %v", ErrDeadlineNotExpired, order.DeadlineAt, now)
 	}
-	if order.State == model.StateDelivered {
-		return fmt.Errorf("%w: ID %d", ErrOrderAlreadyDelivered, id)
+
+	reason := "истек срок хранения"
+	if order.State == model.StateReturned {
+		reason = "возврат клиента передан курьеру"
 	}
 
-	return s.repo.Delete(id)
+	return s.changeState(order, model.StateReturnedToCourier, now, commandReturnToCourier, reason)
 }
 
-// DeliverOrder - доставляет заказ клиенту, если заказ принадлежит клиенту и не просрочен
-func (s *OrderService) DeliverOrder(id, customerID int64, now time.Time) error {
+// DeliverOrder - доставляет заказ клиенту, если заказ принадлежит клиенту, не просрочен
+// и клиент назвал верный код выдачи
+func (s *OrderService) DeliverOrder(id, customerID int64, code string) error {
+	return s.DeliverOrders([]Handout{{OrderID: id, Code: code}}, customerID)
+}
+
+// deliverOrder - выдает заказ клиенту, код выдачи уже проверен. Код погашается, к стоимости
+// добавляется плата за хранение
+func (s *OrderService) deliverOrder(id, customerID int64) error {
+	now := s.clock.Now()
 	order, err := s.repo.FindByID(id)
 	if err != nil {
 		return fmt.Errorf("ошибка при доставке заказа Id %d: %w", id, err)
@@ -121,22 +309,27 @@ func (s *OrderService) DeliverOrder(id, customerID int64, now time.Time) error {
 	if order.CustomerID != customerID {
 		return fmt.Errorf("%w: ID %d", ErrWrongCustomer, id)
 	}
-	if order.State != model.StateAccepted {
-		return fmt.Errorf("%w: ID %d", ErrWrongState, id)
-	}
-	if now.After(order.DeadlineAt) {
+	if order.State == model.StateAccepted && now.After(order.DeadlineAt) {
 		return fmt.Errorf("%w: %v \n Текущая дата: %v", ErrStorageExpired, order.DeadlineAt, now)
 	}
 
-	order.State = model.StateDelivered
-	order.UpdatedAt = now
-	order.DeliveredAt = &now
+	order.StorageFee = s.storageFee(order, now)
+	addCharge(&order, ruleStorage, "Платное хранение", order.StorageFee)
+	order.PickupCodeHash = ""
+	order.PickupAttempts = 0
+	order.PickupLockedUntil = nil
 
-	return s.repo.Update(order)
+	return s.changeState(order, model.StateDelivered, now, commandHandout, "выдан клиенту")
 }
 
 // ProcessReturnOrder - обрабатывает возврат заказа от клиента, если соблюдены условия возврата
-func (s *OrderService) ProcessReturnOrder(id, customerID int64, now time.Time) error {
+func (s *OrderService) ProcessReturnOrder(id, customerID int64) error {
+	return s.ProcessReturnOrders([]int64{id}, customerID)
+}
+
+// processReturnOrder - принимает возврат заказа от клиента в транзакции
+func (s *OrderService) processReturnOrder(id, customerID int64) error {
+	now := s.clock.Now()
 	order, err := s.repo.FindByID(id)
 	if err != nil {
 		return fmt.Errorf("ошибка при возврате заказа Id %d: %w", id, err)
@@ -144,54 +337,168 @@ func (s *OrderService) ProcessReturnOrder(id, customerID int64, now time.Time) e
 	if order.CustomerID != customerID {
 		return fmt.Errorf("%w: ID %d", ErrWrongCustomer, id)
 	}
-	if order.State != model.StateDelivered {
-		return fmt.Errorf("%w: ID %d", ErrNotDelivered, id)
-	}
-	if now.Sub(*order.DeliveredAt) > ReturnedAt {
+	if order.State == model.StateDelivered && now.Sub(*order.DeliveredAt) > s.policy.ReturnWindow.Duration {
 		return fmt.Errorf("%w: %v \n Текущая дата: %v", ErrReturnExpired, order.DeliveredAt, now)
 	}
 
-	order.State = model.StateReturned
-	order.UpdatedAt = now
-	order.ReturnedAt = &now
+	if !order.State.CanTransitionTo(model.StateReturned) {
+		return transitionError(order.ID, order.State, model.StateReturned, model.ErrInvalidTransition)
+	}
+
+	// возврат лежит в ПВЗ до передачи курьеру, поэтому ему, как и принятому заказу, назначается ячейка
+	cell, err := s.assignCell(order)
+	if err != nil {
+		return fmt.Errorf("ошибка при возврате заказа Id %d: %w", id, err)
+	}
+	order.Cell = cell
+
+	return s.changeState(order, model.StateReturned, now, commandCustomerReturn, withCell("возврат от клиента", cell))
+}
 
-	return s.repo.Update(order)
+// ParseDeadline - разбирает срок хранения в формате "YYYY-MM-DDTHH:MM:SS" или длительность от текущего времени
+func (s *OrderService) ParseDeadline(deadline string) (time.Time, error) {
+	return parseDeadline(deadline, s.clock.Now())
+}
+
+// DeliverOrders - выдает клиенту несколько заказов по кодам выдачи: либо выдаются все, либо ни один.
+// Коды проверяются в той же транзакции, что и выдача: неверная попытка сохраняется, а заказы не выдаются
+func (s *OrderService) DeliverOrders(handouts []Handout, customerID int64) error {
+	var wrongCode error
+	err := s.withTx(func(tx *OrderService) error {
+		if err := tx.verifyHandouts(handouts, customerID); err != nil {
+			if errors.Is(err, ErrWrongPickupCode) {
+				wrongCode = err
+				return nil
+			}
+			return err
+		}
+
+		for _, handout := range handouts {
+			if err := tx.deliverOrder(handout.OrderID, customerID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return wrongCode
+}
+
+// ProcessReturnOrders - принимает возврат нескольких заказов клиента: либо все, либо ни одного
+func (s *OrderService) ProcessReturnOrders(ids []int64, customerID int64) error {
+	return s.withTx(func(tx *OrderService) error {
+		for _, id := range ids {
+			if err := tx.processReturnOrder(id, customerID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// withTx - выполняет fn над копией сервиса, работающей в транзакции репозитория.
+// Если журнал событий хранится в той же базе, что и заказы, события записываются в этой же транзакции.
+// Иначе события и коды выдачи, записанные внутри fn, попадают в журнал и выгрузку только после успешного
+// завершения транзакции. Сбой записи в отдельный журнал уже не отменяет изменения заказов, поэтому
+// он не возвращается как ошибка операции, а только выводится в лог: событие может быть потеряно
+func (s *OrderService) withTx(fn func(tx *OrderService) error) error {
+	if s.pending != nil {
+		return fn(s)
+	}
+
+	pending := &pendingWrites{}
+	err := s.store.WithTx(func(tx repository.Repository) error {
+		txService := *s
+		txService.store = tx
+		txService.repo = s.scope(tx)
+		txService.pending = pending
+		if err := fn(&txService); err != nil {
+			return err
+		}
+
+		return s.appendEventsInTx(tx, pending)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = s.events.Append(pending.events...); err != nil {
+		log.Printf("изменения заказов сохранены, но события не записаны в журнал: %v", err)
+	}
+	if err = s.codes.Send(pending.codes...); err != nil {
+		return fmt.Errorf("ошибка выгрузки кодов выдачи: %w", err)
+	}
+
+	return nil
+}
+
+// appendEventsInTx - записывает отложенные события в транзакции tx, если журнал событий - это сам
+// репозиторий заказов. Записанные события убираются из pending
+func (s *OrderService) appendEventsInTx(tx repository.Repository, pending *pendingWrites) error {
+	storeLog, ok := s.store.(audit.Log)
+	if !ok || storeLog != s.events {
+		return nil
+	}
+	txLog, ok := tx.(audit.Log)
+	if !ok {
+		return nil
+	}
+
+	if err := txLog.Append(pending.events...); err != nil {
+		return fmt.Errorf("ошибка записи событий заказов: %w", err)
+	}
+	pending.events = nil
+
+	return nil
+}
+
+// pendingWrites - события и коды выдачи, отложенные до завершения транзакции
+type pendingWrites struct {
+	events []model.OrderEvent
+	codes  []model.PickupNotice
 }
 
 // OrderHistory - возвращает историю заказов, отсортированную по времени обновления (от новых к старым)
-func (s *OrderService) OrderHistory() []model.Order {
-	history := s.repo.List()
+func (s *OrderService) OrderHistory() ([]model.Order, error) {
+	history, err := s.repo.List()
+	if err != nil {
+		return nil, err
+	}
 	sort.Slice(history, func(i, j int) bool {
 		return history[i].UpdatedAt.After(history[j].UpdatedAt)
 	})
 
-	return history
+	return history, nil
 }
 
 // ListReturns - возвращает список возвращенных заказов для указанной страницы и размера страницы
-func (s *OrderService) ListReturns() []model.Order {
-	var returnsList []model.Order
-	for _, order := range s.repo.List() {
-		if order.State == model.StateReturned {
-			returnsList = append(returnsList, order)
-		}
+func (s *OrderService) ListReturns() ([]model.Order, error) {
+	returnsList, err := s.repo.ListByState(model.StateReturned)
+	if err != nil {
+		return nil, err
 	}
 
 	sort.Slice(returnsList, func(i, j int) bool {
 		return returnsList[i].ReturnedAt.After(*returnsList[j].ReturnedAt)
 	})
 
-	return returnsList
+	return returnsList, nil
 }
 
 // ListOrders - возвращает список заказов клиента с возможностью фильтрации и ограничения количества
-func (s *OrderService) ListOrders(customerID int64, lastN int, filterPVZ bool) []model.Order {
+func (s *OrderService) ListOrders(customerID int64, lastN int, filterPVZ bool) ([]model.Order, error) {
+	customerOrders, err := s.repo.ListByCustomer(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
 	var ordersList []model.Order
-	for _, order := range s.repo.List() {
-		if order.CustomerID != customerID {
-			continue
-		}
-		if filterPVZ && (order.State != model.StateAccepted || time.Now().After(order.DeadlineAt)) {
+	for _, order := range customerOrders {
+		if filterPVZ && (order.State != model.StateAccepted || now.After(order.DeadlineAt)) {
 			continue
 		}
 		ordersList = append(ordersList, order)
@@ -203,37 +510,5 @@ func (s *OrderService) ListOrders(customerID int64, lastN int, filterPVZ bool) [
 		ordersList = ordersList[:lastN]
 	}
 
-	return ordersList
-}
-
-// AcceptOrdersFromFile - принимает заказы из файла с форматом JSON
-func (s *OrderService) AcceptOrdersFromFile(filename string) error {
-	orders, err := readOrdersFromFile(filename)
-	if err != nil {
-		return err
-	}
-
-	for _, order := range orders {
-		deadline, err := parseDeadline(order.DeadlineAt)
-		if err != nil {
-			return err
-		}
-
-		packageType, wrapper := processPackaging(order.PackageType, order.Wrapper)
-
-		if err = s.AcceptOrder(
-			order.ID,
-			order.CustomerID,
-			deadline,
-			order.Weight,
-			order.Cost,
-			packageType,
-			wrapper,
-		); err != nil {
-			return fmt.Errorf("ошибка при принятии заказа %d: %w", order.ID, err)
-		}
-		fmt.Printf("заказ %d принят\n", order.ID)
-	}
-
-	return nil
+	return ordersList, nil
 }
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/clock"
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/notify"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+	"gitlab.ozon.dev/gojhw1/pkg/repository"
+)
+
+var testNow = time.Date(2030, 2, 20, 12, 0, 0, 0, time.UTC)
+
+// newTestService - создает сервис с репозиторием в памяти и управляемыми часами.
+// change - изменяет политику по умолчанию до создания сервиса
+func newTestService(t *testing.T, change func(p *policy.Policy)) (*OrderService, *clock.Fake) {
+	t.Helper()
+
+	fake := clock.NewFake(testNow)
+	s := NewOrderService(repository.NewInMemoryRepository(), WithClock(fake), WithPolicy(testPolicy(t, change)))
+
+	return s, fake
+}
+
+// newConcurrentTestService - создает сервис, как newTestService, но обращения к репозиторию вне
+// транзакций уступают процессор, чтобы шаги параллельных операций чередовались
+func newConcurrentTestService(t *testing.T, change func(p *policy.Policy)) *OrderService {
+	t.Helper()
+
+	repo := yieldingRepository{repository.NewInMemoryRepository()}
+	return NewOrderService(repo, WithClock(clock.NewFake(testNow)), WithPolicy(testPolicy(t, change)))
+}
+
+// testPolicy - возвращает политику по умолчанию, измененную change
+func testPolicy(t *testing.T, change func(p *policy.Policy)) *policy.Policy {
+	t.Helper()
+
+	p := policy.Default()
+	if change != nil {
+		change(p)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("политика: %v", err)
+	}
+
+	return p
+}
+
+// yieldingRepository - репозиторий, который перед каждым обращением вне транзакции уступает процессор
+// другим горутинам. Транзакции выполняются на репозитории без задержек
+type yieldingRepository struct {
+	repository.Repository
+}
+
+func (r yieldingRepository) FindByID(id int64) (model.Order, error) {
+	runtime.Gosched()
+	return r.Repository.FindByID(id)
+}
+
+func (r yieldingRepository) Update(order model.Order) error {
+	runtime.Gosched()
+	return r.Repository.Update(order)
+}
+
+func (r yieldingRepository) ListByState(state model.OrderState) ([]model.Order, error) {
+	runtime.Gosched()
+	return r.Repository.ListByState(state)
+}
+
+// acceptTestOrder - принимает заказ в коробке со сроком хранения 48 часов
+func acceptTestOrder(t *testing.T, s *OrderService, id, customerID int64) model.Order {
+	t.Helper()
+
+	box := model.PackageBox
+	if err := s.AcceptOrder(id, customerID, s.Now().Add(48*time.Hour), 1, 100, &box, nil, AcceptOptions{}); err != nil {
+		t.Fatalf("AcceptOrder(%d): %v", id, err)
+	}
+
+	order, err := s.Repo().FindByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return order
+}
+
+// acceptParallel - одновременно принимает заказы ids клиента 1 в коробке весом 1 кг
+// и возвращает ошибки в порядке ids
+func acceptParallel(s *OrderService, ids []int64) []error {
+	box := model.PackageBox
+	deadline := s.Now().Add(48 * time.Hour)
+
+	errs := make([]error, len(ids))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = s.AcceptOrder(id, 1, deadline, 1, 100, &box, nil, AcceptOptions{})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	return errs
+}
+
+// sequentialIDs - возвращает номера заказов от 1 до n
+func sequentialIDs(n int) []int64 {
+	ids := make([]int64, n)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+
+	return ids
+}
+
+// countErrors - считает ошибки errs, соответствующие target; остальные ошибки должны быть nil
+func countErrors(t *testing.T, errs []error, target error) int {
+	t.Helper()
+
+	n := 0
+	for _, err := range errs {
+		switch {
+		case errors.Is(err, target):
+			n++
+		case err != nil:
+			t.Errorf("неожиданная ошибка: %v", err)
+		}
+	}
+
+	return n
+}
+
+// pickupCode - возвращает последний код выдачи заказа из выгрузки в памяти
+func pickupCode(t *testing.T, s *OrderService, id int64) string {
+	t.Helper()
+
+	code := ""
+	for _, notice := range s.codes.(*notify.MemoryOutbox).Notices() {
+		if notice.OrderID == id {
+			code = notice.Code
+		}
+	}
+	if code == "" {
+		t.Fatalf("нет кода выдачи заказа %d", id)
+	}
+
+	return code
+}
+
+func TestSetClockIsSharedWithCopies(t *testing.T) {
+	s, _ := newTestService(t, func(p *policy.Policy) {
+		p.Points = []model.PickupPoint{{ID: "msk-1"}, {ID: "msk-2"}}
+	})
+	point, err := s.ForPoint("msk-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	copies := map[string]*OrderService{
+		"пункт выдачи":     point,
+		"оператор":         s.ForActor("operator"),
+		"пункт и оператор": point.ForActor("operator"),
+	}
+
+	target := testNow.Add(100 * time.Hour)
+	s.SetClock(clock.NewFake(target))
+
+	for name, c := range copies {
+		if got := c.Now(); !got.Equal(target) {
+			t.Errorf("%s: Now() = %v, ожидалось %v", name, got, target)
+		}
+	}
+
+	err = point.withTx(func(tx *OrderService) error {
+		if got := tx.Now(); !got.Equal(target) {
+			t.Errorf("транзакция: Now() = %v, ожидалось %v", got, target)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
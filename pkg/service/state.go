@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+// changeState - переводит заказ в состояние to по таблице переходов model, сохраняет его и записывает событие.
+// Вызывается в транзакции (withTx), в которой заказ был прочитан, иначе параллельный запрос может
+// изменить заказ между чтением и сохранением
+func (s *OrderService) changeState(order model.Order, to model.OrderState, now time.Time, command, reason string) error {
+	from := order.State
+	if err := order.TransitionTo(to, now); err != nil {
+		return transitionError(order.ID, from, to, err)
+	}
+
+	if err := s.repo.Update(order); err != nil {
+		return err
+	}
+
+	return s.recordEvent(order.ID, from, to, command, reason)
+}
+
+// transitionError - дополняет ошибку недопустимого перехода ошибкой сервиса, понятной оператору
+func transitionError(id int64, from, to model.OrderState, err error) error {
+	var reason error
+	switch {
+	case to == model.StateDelivered:
+		reason = ErrWrongState
+	case to == model.StateReturned:
+		reason = ErrNotDelivered
+	case to == model.StateInTransit:
+		reason = ErrNotStored
+	case from == model.StateDelivered:
+		reason = ErrOrderAlreadyDelivered
+	case from == model.StateReturnedToCourier:
+		reason = ErrAlreadyReturnedToCourier
+	default:
+		return fmt.Errorf("ID %d: %w", id, err)
+	}
+
+	return fmt.Errorf("%w: ID %d: %w", reason, id, err)
+}
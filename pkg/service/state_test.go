@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/clock"
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+func TestTransitionError(t *testing.T) {
+	tests := []struct {
+		name string
+		from model.OrderState
+		to   model.OrderState
+		want error
+	}{
+		{name: "выдача не из хранения", from: model.StateReturned, to: model.StateDelivered, want: ErrWrongState},
+		{name: "возврат невыданного", from: model.StateAccepted, to: model.StateReturned, want: ErrNotDelivered},
+		{name: "перемещение не из хранения", from: model.StateDelivered, to: model.StateInTransit, want: ErrNotStored},
+		{name: "возврат курьеру выданного", from: model.StateDelivered, to: model.StateReturnedToCourier, want: ErrOrderAlreadyDelivered},
+		{name: "повторный возврат курьеру", from: model.StateReturnedToCourier, to: model.StateReturnedToCourier, want: ErrAlreadyReturnedToCourier},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := model.Order{ID: 1, State: tt.from}
+			err := order.TransitionTo(tt.to, testNow)
+			if err == nil {
+				t.Fatalf("переход %q -> %q допущен", tt.from, tt.to)
+			}
+
+			err = transitionError(order.ID, tt.from, tt.to, err)
+			if !errors.Is(err, tt.want) || !errors.Is(err, model.ErrInvalidTransition) {
+				t.Errorf("ошибка = %v, ожидалась %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// runParallel - одновременно выполняет fn n раз и возвращает ошибки в порядке запуска
+func runParallel(n int, fn func() error) []error {
+	errs := make([]error, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = fn()
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	return errs
+}
+
+// countTransitions - считает события заказа id с переходом в состояние to
+func countTransitions(t *testing.T, s *OrderService, id int64, to model.OrderState) int {
+	t.Helper()
+
+	events, err := s.OrderEvents(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, event := range events {
+		if event.ToState == to {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestParallelStateChanges(t *testing.T) {
+	const parallel = 8
+
+	tests := []struct {
+		name string
+		// prepare - приводит заказ 1 клиента 1 в исходное состояние
+		prepare func(t *testing.T, s *OrderService)
+		change  func(s *OrderService) error
+		to      model.OrderState
+		wantErr error
+	}{
+		{
+			name: "возврат курьеру",
+			prepare: func(t *testing.T, s *OrderService) {
+				acceptTestOrder(t, s, 1, 1)
+				s.SetClock(clock.NewFake(testNow.Add(72 * time.Hour)))
+			},
+			change:  func(s *OrderService) error { return s.ReturnOrderToCourier(1) },
+			to:      model.StateReturnedToCourier,
+			wantErr: ErrAlreadyReturnedToCourier,
+		},
+		{
+			name: "возврат от клиента",
+			prepare: func(t *testing.T, s *OrderService) {
+				acceptTestOrder(t, s, 1, 1)
+				if err := s.DeliverOrder(1, 1, pickupCode(t, s, 1)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			change:  func(s *OrderService) error { return s.ProcessReturnOrder(1, 1) },
+			to:      model.StateReturned,
+			wantErr: ErrNotDelivered,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newConcurrentTestService(t, nil)
+			tt.prepare(t, s)
+
+			// состояние проверяется в транзакции, поэтому переход выполняет только один из запросов
+			errs := runParallel(parallel, func() error { return tt.change(s) })
+			if failed := countErrors(t, errs, tt.wantErr); failed != parallel-1 {
+				t.Errorf("отклонено %d запросов, ожидалось %d", failed, parallel-1)
+			}
+			if n := countTransitions(t, s, 1, tt.to); n != 1 {
+				t.Errorf("событий перехода в %q: %d, ожидалось 1", tt.to, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/storage"
+)
+
+var (
+	ErrCheckpointMismatch = errors.New("контрольная точка относится к другому файлу")
+	ErrReadCheckpoint     = errors.New("ошибка чтения контрольной точки")
+	ErrWriteCheckpoint    = errors.New("ошибка записи контрольной точки")
+)
+
+// DefaultCheckpointEvery - число записей между контрольными точками по умолчанию
+const DefaultCheckpointEvery = 1000
+
+// StreamOptions - параметры потокового приема заказов
+type StreamOptions struct {
+	// CheckpointPath - файл контрольной точки. Пустая строка - без контрольных точек
+	CheckpointPath string
+	// CheckpointEvery - число записей в одной порции; после каждой порции записывается контрольная точка
+	CheckpointEvery int
+	// Resume - продолжить прием с последней контрольной точки
+	Resume bool
+	// Commit - сохраняет заказы, принятые в порции; вызывается после каждой порции до записи контрольной точки
+	Commit func(orders []model.Order) error
+	// Progress - вызывается после каждой порции
+	Progress func(StreamProgress)
+	// Result - вызывается для каждой обработанной записи после сохранения ее порции
+	Result func(AcceptResult)
+}
+
+// StreamProgress - ход потокового приема
+type StreamProgress struct {
+	Records    int
+	Accepted   int
+	Rejected   int
+	BytesRead  int64
+	TotalBytes int64
+}
+
+// checkpoint - состояние потокового приема, сохраненное после очередной порции
+type checkpoint struct {
+	File     string `json:"file"`
+	Size     int64  `json:"size"`
+	Records  int    `json:"records"`
+	Accepted int    `json:"accepted"`
+	Rejected int    `json:"rejected"`
+	// Committing - заказы порции, сохранение которой было начато. Если прием прервался
+	// во время сохранения, эти заказы могут уже быть в репозитории
+	Committing []int64 `json:"committing,omitempty"`
+}
+
+// StreamOrdersFromFile - принимает заказы из файла порциями, не загружая его в память целиком.
+// Корректные записи принимаются, остальные отклоняются (как в BatchBestEffort). Каждая порция
+// сохраняется в одной транзакции, после чего записывается контрольная точка, с которой прием
+// можно продолжить при opts.Resume. Возвращается отчет без результатов по отдельным записям
+func (s *OrderService) StreamOrdersFromFile(filename string, importOpts ImportOptions, opts StreamOptions) (*BatchReport, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenFile, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReadFile, err)
+	}
+
+	cp, err := startCheckpoint(filename, info.Size(), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	counter := &countingReader{r: file}
+	records, err := newRecordReader(counter, filename, importOpts)
+	if err != nil {
+		return nil, err
+	}
+	if err = skipRecords(records, cp.Records); err != nil {
+		return nil, err
+	}
+
+	if err = s.streamChunks(records, cp, counter, info.Size(), opts); err != nil {
+		return nil, err
+	}
+
+	if opts.CheckpointPath != "" {
+		if err = os.Remove(opts.CheckpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %w", ErrWriteCheckpoint, err)
+		}
+	}
+
+	return &BatchReport{
+		Mode:     BatchBestEffort,
+		Total:    cp.Records,
+		Accepted: cp.Accepted,
+		Rejected: cp.Rejected,
+	}, nil
+}
+
+// streamChunks - читает записи порциями и принимает каждую порцию, обновляя контрольную точку
+func (s *OrderService) streamChunks(records recordReader, cp *checkpoint, counter *countingReader, total int64, opts StreamOptions) error {
+	size := opts.CheckpointEvery
+	if size <= 0 {
+		size = DefaultCheckpointEvery
+	}
+
+	chunk := make([]orderRecord, 0, size)
+	for done := false; !done; {
+		var err error
+		chunk, done, err = readChunk(records, chunk[:0], size)
+		if err != nil {
+			return err
+		}
+		if len(chunk) == 0 {
+			continue
+		}
+
+		if err = s.acceptChunk(chunk, cp, opts); err != nil {
+			return err
+		}
+		if opts.Progress != nil {
+			opts.Progress(StreamProgress{
+				Records:    cp.Records,
+				Accepted:   cp.Accepted,
+				Rejected:   cp.Rejected,
+				BytesRead:  counter.n,
+				TotalBytes: total,
+			})
+		}
+	}
+
+	return nil
+}
+
+// acceptChunk - принимает порцию записей в одной транзакции, сохраняет данные и контрольную точку
+func (s *OrderService) acceptChunk(chunk []orderRecord, cp *checkpoint, opts StreamOptions) error {
+	// при повторной обработке прерванной порции ее уже сохраненные заказы считаются принятыми
+	committed := make(map[int64]bool, len(cp.Committing))
+	for _, id := range cp.Committing {
+		committed[id] = true
+	}
+
+	// контрольная точка с заказами порции записывается до фиксации транзакции: хранилище
+	// может сохранить их уже при фиксации (SQL) или позже в opts.Commit
+	results := make([]AcceptResult, len(chunk))
+	err := s.withTx(func(tx *OrderService) error {
+		now := tx.clock.Now()
+		for i, record := range chunk {
+			results[i] = tx.acceptRecord(record, now, committed)
+		}
+
+		committing := *cp
+		committing.Committing = acceptedIDs(results)
+		return saveCheckpoint(opts.CheckpointPath, committing)
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.Commit != nil {
+		orders, err := s.acceptedOrders(results)
+		if err != nil {
+			return err
+		}
+		if err = opts.Commit(orders); err != nil {
+			return err
+		}
+	}
+
+	next := *cp
+	next.Committing = nil
+	for _, result := range results {
+		next.Records++
+		if result.Status == AcceptStatusAccepted {
+			next.Accepted++
+		} else {
+			next.Rejected++
+		}
+	}
+	if err = saveCheckpoint(opts.CheckpointPath, next); err != nil {
+		return err
+	}
+	*cp = next
+
+	if opts.Result != nil {
+		for _, result := range results {
+			opts.Result(result)
+		}
+	}
+
+	return nil
+}
+
+// acceptedOrders - возвращает заказы, принятые в порции
+func (s *OrderService) acceptedOrders(results []AcceptResult) ([]model.Order, error) {
+	orders := make([]model.Order, 0, len(results))
+	for _, result := range results {
+		if result.Status != AcceptStatusAccepted {
+			continue
+		}
+
+		order, err := s.repo.FindByID(result.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+// acceptedIDs - возвращает номера заказов, принятых в порции
+func acceptedIDs(results []AcceptResult) []int64 {
+	var ids []int64
+	for _, result := range results {
+		if result.Status == AcceptStatusAccepted {
+			ids = append(ids, result.OrderID)
+		}
+	}
+
+	return ids
+}
+
+// acceptRecord - проверяет и принимает одну запись. Повторы внутри файла обнаруживаются
+// репозиторием, так как предыдущие записи к этому моменту уже добавлены.
+// committed - заказы прерванной порции, уже сохраненные до прерывания приема; каждый
+// из них засчитывается принятым один раз, повтор в файле остается повтором
+func (s *OrderService) acceptRecord(record orderRecord, now time.Time, committed map[int64]bool) AcceptResult {
+	result := AcceptResult{Line: record.Line, OrderID: record.Data.ID, Status: AcceptStatusAccepted}
+
+	order, err := s.validateRecord(record, now, nil)
+	if err == nil {
+		err = s.addOrder(order, commandAcceptOrdersFile)
+	}
+	if errors.Is(err, ErrOrderExists) && committed[record.Data.ID] {
+		delete(committed, record.Data.ID)
+		return result
+	}
+	if err != nil {
+		result.Status = AcceptStatusRejected
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// readChunk - дописывает в chunk до size записей. done - записи в файле закончились
+func readChunk(records recordReader, chunk []orderRecord, size int) ([]orderRecord, bool, error) {
+	for len(chunk) < size {
+		record, err := records.Next()
+		if errors.Is(err, io.EOF) {
+			return chunk, true, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		chunk = append(chunk, record)
+	}
+
+	return chunk, false, nil
+}
+
+// skipRecords - пропускает n записей, обработанных до контрольной точки
+func skipRecords(records recordReader, n int) error {
+	for range n {
+		if _, err := records.Next(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("%w: в файле меньше записей, чем обработано", ErrCheckpointMismatch)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// startCheckpoint - возвращает начальное состояние приема: сохраненное, если нужно продолжить, иначе пустое
+func startCheckpoint(filename string, size int64, opts StreamOptions) (*checkpoint, error) {
+	cp := &checkpoint{File: filename, Size: size}
+	if !opts.Resume || opts.CheckpointPath == "" {
+		return cp, nil
+	}
+
+	data, err := os.ReadFile(opts.CheckpointPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReadCheckpoint, err)
+	}
+
+	var saved checkpoint
+	if err = json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReadCheckpoint, err)
+	}
+	if saved.File != filename || saved.Size != size {
+		return nil, fmt.Errorf("%w: %s (%d байт)", ErrCheckpointMismatch, saved.File, saved.Size)
+	}
+
+	return &saved, nil
+}
+
+// saveCheckpoint - атомарно записывает контрольную точку и сбрасывает ее на диск,
+// чтобы после сбоя она не отставала от уже сохраненных заказов
+func saveCheckpoint(path string, cp checkpoint) error {
+	if path == "" {
+		return nil
+	}
+
+	err := storage.WriteFileAtomic(path, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(cp)
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteCheckpoint, err)
+	}
+
+	return nil
+}
+
+// countingReader - считает прочитанные байты для индикатора прогресса
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read - читает данные из источника
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+
+	return n, err
+}
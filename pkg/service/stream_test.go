@@ -0,0 +1,246 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+var errCommitFailed = errors.New("сбой сохранения")
+
+// writeManifest - записывает NDJSON файл с заказами ids; отрицательный вес делает запись некорректной
+func writeManifest(t *testing.T, ids []int64, invalid int64) string {
+	t.Helper()
+
+	var b strings.Builder
+	for _, id := range ids {
+		weight := 1.0
+		if id == invalid {
+			weight = -1
+		}
+		fmt.Fprintf(&b, `{"id": %d, "customer_id": 1, "deadline_at": "48h", "weight": %g, "cost": 100}`+"\n", id, weight)
+	}
+
+	path := filepath.Join(t.TempDir(), "orders.ndjson")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func orderIDs(orders []model.Order) []int64 {
+	ids := make([]int64, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.ID)
+	}
+	slices.Sort(ids)
+
+	return ids
+}
+
+func readCheckpoint(t *testing.T, path string) checkpoint {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cp checkpoint
+	if err = json.Unmarshal(data, &cp); err != nil {
+		t.Fatalf("контрольная точка %q: %v", data, err)
+	}
+
+	return cp
+}
+
+func TestStreamOrdersCommitsChunks(t *testing.T) {
+	s, _ := newTestService(t, nil)
+	manifest := writeManifest(t, []int64{1, 2, 3, 4, 5}, 4)
+	checkpointPath := manifest + ".checkpoint"
+
+	var commits [][]int64
+	report, err := s.StreamOrdersFromFile(manifest, ImportOptions{}, StreamOptions{
+		CheckpointPath:  checkpointPath,
+		CheckpointEvery: 2,
+		Commit: func(orders []model.Order) error {
+			commits = append(commits, orderIDs(orders))
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// сохраняются только заказы, принятые в очередной порции
+	want := [][]int64{{1, 2}, {3}, {5}}
+	if !slices.EqualFunc(commits, want, slices.Equal[[]int64]) {
+		t.Errorf("сохраненные порции = %v, ожидалось %v", commits, want)
+	}
+	if report.Total != 5 || report.Accepted != 4 || report.Rejected != 1 {
+		t.Errorf("отчет = %+v", report)
+	}
+	if _, err = os.Stat(checkpointPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("контрольная точка не удалена после завершения: %v", err)
+	}
+}
+
+func TestStreamOrdersResume(t *testing.T) {
+	tests := []struct {
+		name string
+		// existing - заказы, принятые до начала приема
+		existing []int64
+		// failAt - номер вызова Commit (с 1), на котором сохранение прерывается
+		failAt         int
+		wantCheckpoint checkpoint
+		wantResumed    []int64
+		wantRejected   int
+	}{
+		{
+			name:           "сбой при сохранении первой порции",
+			failAt:         1,
+			wantCheckpoint: checkpoint{Records: 0, Committing: []int64{1, 2}},
+			wantResumed:    []int64{1, 2, 3, 4, 5},
+		},
+		{
+			name:           "сбой при сохранении второй порции",
+			failAt:         2,
+			wantCheckpoint: checkpoint{Records: 2, Accepted: 2, Committing: []int64{3, 4}},
+			wantResumed:    []int64{3, 4, 5},
+		},
+		{
+			// заказ 3 был повтором до начала приема и не входит в прерванную порцию
+			name:           "повтор в прерванной порции",
+			existing:       []int64{3},
+			failAt:         2,
+			wantCheckpoint: checkpoint{Records: 2, Accepted: 2, Committing: []int64{4}},
+			wantResumed:    []int64{4, 5},
+			wantRejected:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t, nil)
+			for _, id := range tt.existing {
+				acceptTestOrder(t, s, id, 1)
+			}
+			manifest := writeManifest(t, []int64{1, 2, 3, 4, 5}, 0)
+			opts := StreamOptions{CheckpointPath: manifest + ".checkpoint", CheckpointEvery: 2}
+
+			calls := 0
+			opts.Commit = func([]model.Order) error {
+				calls++
+				if calls == tt.failAt {
+					return errCommitFailed
+				}
+				return nil
+			}
+			if _, err := s.StreamOrdersFromFile(manifest, ImportOptions{}, opts); !errors.Is(err, errCommitFailed) {
+				t.Fatalf("ошибка = %v, ожидалась %v", err, errCommitFailed)
+			}
+
+			cp := readCheckpoint(t, opts.CheckpointPath)
+			cp.File, cp.Size = "", 0
+			if !reflect.DeepEqual(cp, tt.wantCheckpoint) {
+				t.Errorf("контрольная точка = %+v, ожидалась %+v", cp, tt.wantCheckpoint)
+			}
+
+			// заказы прерванной порции уже в репозитории: при продолжении они не считаются повторами
+			var resumed []int64
+			opts.Resume = true
+			opts.Commit = func(orders []model.Order) error {
+				resumed = append(resumed, orderIDs(orders)...)
+				return nil
+			}
+			report, err := s.StreamOrdersFromFile(manifest, ImportOptions{}, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Total != 5 || report.Accepted != 5-tt.wantRejected || report.Rejected != tt.wantRejected {
+				t.Errorf("отчет = %+v", report)
+			}
+			if !slices.Equal(resumed, tt.wantResumed) {
+				t.Errorf("при продолжении сохранены %v, ожидалось %v", resumed, tt.wantResumed)
+			}
+
+			all, err := s.Repo().GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != 5 {
+				t.Errorf("заказов в репозитории = %d, ожидалось 5", len(all))
+			}
+		})
+	}
+}
+
+func TestStreamOrdersCheckpointMismatch(t *testing.T) {
+	s, _ := newTestService(t, nil)
+	manifest := writeManifest(t, []int64{1, 2, 3}, 0)
+	checkpointPath := manifest + ".checkpoint"
+	if err := saveCheckpoint(checkpointPath, checkpoint{File: "other.ndjson", Size: 1, Records: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := s.StreamOrdersFromFile(manifest, ImportOptions{}, StreamOptions{CheckpointPath: checkpointPath, Resume: true})
+	if !errors.Is(err, ErrCheckpointMismatch) {
+		t.Errorf("ошибка = %v, ожидалась %v", err, ErrCheckpointMismatch)
+	}
+}
+
+func TestStreamOrdersLargeInput(t *testing.T) {
+	const (
+		existing = 50_000
+		records  = 5_000
+	)
+
+	s, _ := newTestService(t, nil)
+	orders := make(map[int64]model.Order, existing)
+	for id := int64(records + 1); id <= records+existing; id++ {
+		orders[id] = model.Order{ID: id, CustomerID: 1, State: model.StateAccepted}
+	}
+	if err := s.Repo().SetAll(orders); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]int64, records)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	manifest := writeManifest(t, ids, 0)
+
+	// каждая запись сохраняется в своей транзакции: транзакция не копирует заказы репозитория,
+	// поэтому прием не замедляется с ростом их числа
+	chunks := 0
+	report, err := s.StreamOrdersFromFile(manifest, ImportOptions{}, StreamOptions{
+		CheckpointEvery: 1,
+		Commit: func([]model.Order) error {
+			chunks++
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != records || report.Accepted != records || chunks != records {
+		t.Errorf("отчет = %+v, порций %d", report, chunks)
+	}
+
+	all, err := s.Repo().GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != existing+records {
+		t.Errorf("заказов в репозитории = %d, ожидалось %d", len(all), existing+records)
+	}
+}
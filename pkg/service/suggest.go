@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/packaging"
+)
+
+var (
+	ErrNoSuitablePackage = errors.New("ни один тип упаковки не подходит для заказа")
+	ErrAutoWithWrappers  = errors.New("при автоматическом подборе упаковки обертки подбираются автоматически и не указываются")
+)
+
+// maxWrapperCombination - сколько оберток, увеличивающих максимальный вес, перебирается в сочетаниях
+// при подборе упаковки. Перебор полный: до 2^8-1 = 255 сочетаний на каждый тип упаковки, поэтому
+// остальные такие обертки (после первых по алфавиту) не рассматриваются
+const maxWrapperCombination = 8
+
+// PackageOption - вариант упаковки, рассмотренный при подборе
+type PackageOption struct {
+	PackageType model.PackageType   `json:"package_type"`
+	Wrappers    []model.WrapperType `json:"wrappers,omitempty"`
+	// Cost - стоимость упаковки с обертками
+	Cost float64 `json:"cost"`
+	// Reason - почему вариант не подходит; пустая строка - подходит
+	Reason string `json:"reason,omitempty"`
+}
+
+// Valid - подходит ли вариант для заказа
+func (o PackageOption) Valid() bool {
+	return o.Reason == ""
+}
+
+// String - выводит упаковку с обертками как в accept_order: "box+film"
+func (o PackageOption) String() string {
+	parts := []string{string(o.PackageType)}
+	for _, wrapper := range o.Wrappers {
+		parts = append(parts, string(wrapper))
+	}
+
+	return strings.Join(parts, "+")
+}
+
+// PackageSuggestion - результат подбора упаковки: самый дешевый подходящий вариант
+// и по одному варианту на каждый тип упаковки из реестра
+type PackageSuggestion struct {
+	// Best - рекомендуемый вариант, nil - ни один тип упаковки не подходит
+	Best    *PackageOption  `json:"best,omitempty"`
+	Options []PackageOption `json:"options"`
+}
+
+// SuggestPackage - подбирает самую дешевую упаковку для заказа весом weight с габаритами dimensions
+// (nil - не указаны). Каждый тип упаковки из реестра проверяется сам по себе, а если заказ для него
+// слишком тяжелый - с сочетаниями оберток, увеличивающих максимальный вес
+func (s *OrderService) SuggestPackage(weight float64, dimensions *model.Dimensions) (PackageSuggestion, error) {
+	if weight <= 0 {
+		return PackageSuggestion{}, fmt.Errorf("%w: %v", ErrNegativeWeight, weight)
+	}
+	if dimensions != nil && !dimensions.Valid() {
+		return PackageSuggestion{}, fmt.Errorf("%w: %s", model.ErrInvalidDimensions, dimensions)
+	}
+
+	var suggestion PackageSuggestion
+	combinations := wrapperCombinations(s.packaging.Wrappers())
+	for _, pkg := range s.packaging.Packages() {
+		option := s.suggestForPackage(pkg.Name, combinations, weight, dimensions)
+		suggestion.Options = append(suggestion.Options, option)
+
+		if option.Valid() && (suggestion.Best == nil || option.Cost < suggestion.Best.Cost) {
+			best := option
+			suggestion.Best = &best
+		}
+	}
+
+	return suggestion, nil
+}
+
+// suggestForPackage - возвращает самый дешевый подходящий вариант упаковки packageType,
+// а если подходящего нет - вариант без оберток с причиной отказа. Обертки подбираются, только если
+// упаковка не подошла по весу: габариты они не меняют, и при других отказах перебор бесполезен
+func (s *OrderService) suggestForPackage(packageType model.PackageType, combinations [][]model.WrapperType, weight float64, dimensions *model.Dimensions) PackageOption {
+	bare, err := s.packageOption(packageType, nil, weight, dimensions)
+	if !errors.Is(err, ErrPackageWeightExceeded) {
+		return bare
+	}
+
+	var best *PackageOption
+	for _, wrappers := range combinations {
+		option, err := s.packageOption(packageType, wrappers, weight, dimensions)
+		if err == nil && (best == nil || option.Cost < best.Cost) {
+			best = &option
+		}
+	}
+	if best == nil {
+		return bare
+	}
+
+	return *best
+}
+
+// packageOption - проверяет вариант упаковки. Если он не подходит, причина записывается в Reason
+// и возвращается как ошибка
+func (s *OrderService) packageOption(packageType model.PackageType, wrappers []model.WrapperType, weight float64, dimensions *model.Dimensions) (PackageOption, error) {
+	option := PackageOption{PackageType: packageType, Wrappers: wrappers}
+
+	cost, err := s.packagingCost(&packageType, wrappers, weight, dimensions)
+	if err != nil {
+		option.Reason = err.Error()
+		return option, err
+	}
+	option.Cost = cost
+
+	return option, nil
+}
+
+// wrapperCombinations - возвращает непустые сочетания оберток, увеличивающих максимальный вес упаковки
+func wrapperCombinations(wrappers []packaging.Wrapper) [][]model.WrapperType {
+	var helpful []model.WrapperType
+	for _, wrapper := range wrappers {
+		if wrapper.MaxWeightAdjustment > 0 && len(helpful) < maxWrapperCombination {
+			helpful = append(helpful, wrapper.Name)
+		}
+	}
+
+	var combinations [][]model.WrapperType
+	for mask := 1; mask < 1<<len(helpful); mask++ {
+		var combination []model.WrapperType
+		for i, wrapper := range helpful {
+			if mask&(1<<i) != 0 {
+				combination = append(combination, wrapper)
+			}
+		}
+		combinations = append(combinations, combination)
+	}
+
+	return combinations
+}
+
+// autoPackaging - подбирает упаковку для заказа, принимаемого с типом упаковки auto
+func (s *OrderService) autoPackaging(wrappers []model.WrapperType, weight float64, dimensions *model.Dimensions) (*model.PackageType, []model.WrapperType, error) {
+	if len(wrappers) > 0 {
+		return nil, nil, ErrAutoWithWrappers
+	}
+
+	suggestion, err := s.SuggestPackage(weight, dimensions)
+	if err != nil {
+		return nil, nil, err
+	}
+	if suggestion.Best == nil {
+		reasons := make([]string, 0, len(suggestion.Options))
+		for _, option := range suggestion.Options {
+			reasons = append(reasons, option.Reason)
+		}
+		return nil, nil, fmt.Errorf("%w: %s", ErrNoSuitablePackage, strings.Join(reasons, "; "))
+	}
+
+	packageType := suggestion.Best.PackageType
+	return &packageType, suggestion.Best.Wrappers, nil
+}
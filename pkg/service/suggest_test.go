@@ -0,0 +1,119 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+// optionSummary - вариант упаковки со стоимостью или "-", если вариант не подходит
+func optionSummary(option PackageOption) string {
+	if !option.Valid() {
+		return option.String() + " -"
+	}
+
+	return fmt.Sprintf("%s %.0f", option, option.Cost)
+}
+
+func TestSuggestPackage(t *testing.T) {
+	s, _ := newTestService(t, func(p *policy.Policy) {
+		p.Packages = map[model.PackageType]policy.PackageSpec{
+			model.PackageBag: {Cost: 5, MaxWeight: 10, MaxDimensions: &model.Dimensions{Length: 40, Width: 40, Height: 40}},
+			model.PackageBox: {Cost: 20, MaxWeight: 30},
+		}
+		p.Wrappers = map[model.WrapperType]policy.WrapperSpec{
+			model.WrapperFilm: {Cost: 1},
+			"strap":           {Cost: 4, MaxWeightAdjustment: 10},
+			"tape":            {Cost: 2, MaxWeightAdjustment: 5},
+		}
+	})
+
+	tests := []struct {
+		name       string
+		weight     float64
+		dimensions *model.Dimensions
+		// wantBest - рекомендуемый вариант, пустая строка - ни один не подходит
+		wantBest    string
+		wantOptions []string
+	}{
+		{
+			name:        "подходит без оберток",
+			weight:      5,
+			wantBest:    "bag 5",
+			wantOptions: []string{"bag 5", "box 20"},
+		},
+		{
+			name:        "обертка дешевле другой упаковки",
+			weight:      14,
+			wantBest:    "bag+tape 7",
+			wantOptions: []string{"bag+tape 7", "box 20"},
+		},
+		{
+			name:        "сочетание оберток",
+			weight:      22,
+			wantBest:    "bag+strap+tape 11",
+			wantOptions: []string{"bag+strap+tape 11", "box 20"},
+		},
+		{
+			// обертки не меняют габариты и не перебираются
+			name:        "не подходит только по габаритам",
+			weight:      5,
+			dimensions:  &model.Dimensions{Length: 50, Width: 10, Height: 10},
+			wantBest:    "box 20",
+			wantOptions: []string{"bag -", "box 20"},
+		},
+		{
+			name:        "ни одна упаковка не подходит",
+			weight:      50,
+			wantOptions: []string{"bag -", "box -"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestion, err := s.SuggestPackage(tt.weight, tt.dimensions)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			best := ""
+			if suggestion.Best != nil {
+				best = optionSummary(*suggestion.Best)
+			}
+			if best != tt.wantBest {
+				t.Errorf("рекомендуется %q, ожидалось %q", best, tt.wantBest)
+			}
+
+			var options []string
+			for _, option := range suggestion.Options {
+				options = append(options, optionSummary(option))
+			}
+			if !slices.Equal(options, tt.wantOptions) {
+				t.Errorf("варианты %v, ожидалось %v", options, tt.wantOptions)
+			}
+		})
+	}
+}
+
+func TestWrapperCombinationsLimit(t *testing.T) {
+	s, _ := newTestService(t, func(p *policy.Policy) {
+		p.Wrappers = map[model.WrapperType]policy.WrapperSpec{model.WrapperFilm: {Cost: 1}}
+		for i := range maxWrapperCombination + 2 {
+			p.Wrappers[model.WrapperType(fmt.Sprintf("strap-%02d", i))] = policy.WrapperSpec{Cost: 1, MaxWeightAdjustment: 1}
+		}
+	})
+
+	// обертки без сдвига ограничения веса и сверх лимита не перебираются
+	combinations := wrapperCombinations(s.Packaging().Wrappers())
+	if want := 1<<maxWrapperCombination - 1; len(combinations) != want {
+		t.Errorf("сочетаний %d, ожидалось %d", len(combinations), want)
+	}
+	for _, combination := range combinations {
+		if slices.ContainsFunc(combination, func(w model.WrapperType) bool { return w == model.WrapperFilm || w >= "strap-08" }) {
+			t.Fatalf("лишняя обертка в сочетании %v", combination)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+var (
+	ErrPointNotSelected = errors.New("пункт выдачи не выбран")
+	ErrSamePoint        = errors.New("заказ уже находится в этом пункте выдачи")
+	ErrNotStored        = errors.New("переместить можно только заказ, хранящийся в ПВЗ")
+)
+
+// TransferOrder - перемещает принятый заказ из текущего пункта выдачи в пункт target.
+// Заказ проходит состояние in_transit и принимается в пункте назначения, где ему назначается ячейка
+// и проверяется вместимость. Срок хранения сохраняется или отсчитывается заново по правилам transfer
+// из политики. Обе смены состояния выполняются в одной транзакции и записываются в историю заказа.
+// Клиенту выпускается новый код выдачи с пунктом назначения, прежний код перестает действовать
+func (s *OrderService) TransferOrder(id int64, target string) error {
+	if s.point == "" {
+		return ErrPointNotSelected
+	}
+	if target == s.point {
+		return fmt.Errorf("%w: %s", ErrSamePoint, target)
+	}
+	if _, err := s.ForPoint(target); err != nil {
+		return err
+	}
+
+	return s.withTx(func(tx *OrderService) error {
+		order, err := tx.dispatchOrder(id, target)
+		if err != nil {
+			return err
+		}
+
+		destination, err := tx.ForPoint(target)
+		if err != nil {
+			return err
+		}
+
+		return destination.receiveOrder(order, tx.point)
+	})
+}
+
+// dispatchOrder - отправляет заказ из текущего пункта в пункт target, освобождая его ячейку
+func (s *OrderService) dispatchOrder(id int64, target string) (model.Order, error) {
+	now := s.clock.Now()
+	order, err := s.repo.FindByID(id)
+	if err != nil {
+		return model.Order{}, fmt.Errorf("ошибка при перемещении заказа Id %d: %w", id, err)
+	}
+	if order.State == model.StateAccepted && now.After(order.DeadlineAt) {
+		return model.Order{}, fmt.Errorf("%w: %v \n Текущая дата: %v", ErrStorageExpired, order.DeadlineAt, now)
+	}
+
+	if err = s.changeState(order, model.StateInTransit, now, commandTransferOrder, "отправлен в пункт выдачи "+target); err != nil {
+		return model.Order{}, err
+	}
+
+	return s.repo.FindByID(id)
+}
+
+// receiveOrder - принимает в текущем пункте заказ, перемещаемый из пункта from, и выпускает
+// новый код выдачи, чтобы уведомление клиента указывало пункт, где заказ теперь хранится
+func (s *OrderService) receiveOrder(order model.Order, from string) error {
+	now := s.clock.Now()
+	if err := order.TransitionTo(model.StateAccepted, now); err != nil {
+		return transitionError(order.ID, model.StateInTransit, model.StateAccepted, err)
+	}
+	order.PointID = s.point
+	if s.policy.Transfer.Deadline == policy.TransferDeadlineReset {
+		order.DeadlineAt = now.Add(s.policy.Transfer.StoragePeriod.Duration)
+	}
+
+	if err := s.checkCapacity(order); err != nil {
+		return err
+	}
+	cell, err := s.assignCell(order)
+	if err != nil {
+		return err
+	}
+	order.Cell = cell
+
+	notice, err := s.issuePickupCode(&order)
+	if err != nil {
+		return err
+	}
+
+	// заказ еще числится за пунктом отправления, поэтому сохраняется через общий репозиторий
+	if err = s.store.Update(order); err != nil {
+		return err
+	}
+
+	reason := withCell("прибыл из пункта выдачи "+from, cell)
+	if err = s.recordEvent(order.ID, model.StateInTransit, model.StateAccepted, commandTransferOrder, reason); err != nil {
+		return err
+	}
+
+	return s.sendCode(notice)
+}
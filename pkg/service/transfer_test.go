@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+	"gitlab.ozon.dev/gojhw1/pkg/notify"
+	"gitlab.ozon.dev/gojhw1/pkg/policy"
+)
+
+func TestTransferOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		transfer policy.TransferSpec
+		// wantDeadline - срок хранения после перемещения относительно момента перемещения
+		wantDeadline time.Duration
+	}{
+		{name: "срок сохраняется", transfer: policy.TransferSpec{Deadline: policy.TransferDeadlinePreserve}, wantDeadline: 47 * time.Hour},
+		{name: "срок отсчитывается заново", transfer: policy.TransferSpec{Deadline: policy.TransferDeadlineReset, StoragePeriod: policy.Duration{Duration: 72 * time.Hour}}, wantDeadline: 72 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, fake := newTestService(t, func(p *policy.Policy) {
+				twoPoints(p)
+				p.Cells = []policy.CellGroup{{Shelf: "A", Count: 1, MaxWeight: 1}}
+				p.Transfer = tt.transfer
+			})
+			source := forPoint(t, s, "msk-1")
+			acceptTestOrder(t, source, 1, 1)
+
+			fake.Advance(time.Hour)
+			if err := source.TransferOrder(1, "msk-2"); err != nil {
+				t.Fatal(err)
+			}
+
+			order, err := forPoint(t, s, "msk-2").Repo().FindByID(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order.State != model.StateAccepted || order.Cell != "A-01" {
+				t.Errorf("в пункте назначения состояние %q, ячейка %q", order.State, order.Cell)
+			}
+			if want := s.Now().Add(tt.wantDeadline); !order.DeadlineAt.Equal(want) {
+				t.Errorf("срок хранения %v, ожидался %v", order.DeadlineAt, want)
+			}
+
+			// ячейка в пункте отправления освобождена
+			acceptTestOrder(t, source, 2, 2)
+
+			events, err := s.OrderEvents(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var moves []model.OrderState
+			for _, event := range events {
+				if event.Command == commandTransferOrder {
+					moves = append(moves, event.FromState, event.ToState)
+				}
+			}
+			want := []model.OrderState{model.StateAccepted, model.StateInTransit, model.StateInTransit, model.StateAccepted}
+			if !slices.Equal(moves, want) {
+				t.Errorf("события перемещения %v, ожидалось %v", moves, want)
+			}
+		})
+	}
+}
+
+func TestTransferOrderReissuesPickupCode(t *testing.T) {
+	s, _ := newTestService(t, twoPoints)
+	source := forPoint(t, s, "msk-1")
+	acceptTestOrder(t, source, 1, 1)
+	oldCode := pickupCode(t, s, 1)
+
+	if err := source.TransferOrder(1, "msk-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// последнее уведомление клиента указывает пункт назначения и новый срок хранения
+	notices := s.codes.(*notify.MemoryOutbox).Notices()
+	notice := notices[len(notices)-1]
+	order, err := s.Repo().FindByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notice.OrderID != 1 || notice.PointID != "msk-2" || !notice.DeadlineAt.Equal(order.DeadlineAt) {
+		t.Errorf("уведомление после перемещения = %+v", notice)
+	}
+
+	// прежний код из уведомления с пунктом отправления больше не действует
+	destination := forPoint(t, s, "msk-2")
+	if err = destination.DeliverOrder(1, 1, oldCode); !errors.Is(err, ErrWrongPickupCode) {
+		t.Errorf("прежний код: ошибка = %v, ожидалась %v", err, ErrWrongPickupCode)
+	}
+	if err = destination.DeliverOrder(1, 1, notice.Code); err != nil {
+		t.Errorf("новый код: %v", err)
+	}
+}
+
+func TestTransferOrderTargetFull(t *testing.T) {
+	s, _ := newTestService(t, func(p *policy.Policy) {
+		twoPoints(p)
+		p.Capacity = policy.CapacitySpec{MaxOrders: 1}
+	})
+	source := forPoint(t, s, "msk-1")
+	acceptTestOrder(t, source, 1, 1)
+	acceptTestOrder(t, forPoint(t, s, "msk-2"), 2, 2)
+
+	if err := source.TransferOrder(1, "msk-2"); !errors.Is(err, ErrCapacityExceeded) {
+		t.Fatalf("ошибка = %v, ожидалась %v", err, ErrCapacityExceeded)
+	}
+
+	// обе смены состояния откатываются: заказ остается в пункте отправления, событий перемещения нет
+	order, err := source.Repo().FindByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.State != model.StateAccepted || order.PointID != "msk-1" {
+		t.Errorf("после отказа состояние %q, пункт %q", order.State, order.PointID)
+	}
+	events, err := s.OrderEvents(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Errorf("после отказа событий %d, ожидалось только событие приема", len(events))
+	}
+}
+
+func TestTransferOrderErrors(t *testing.T) {
+	s, _ := newTestService(t, twoPoints)
+	source := forPoint(t, s, "msk-1")
+	acceptTestOrder(t, source, 1, 1)
+
+	tests := []struct {
+		name    string
+		s       *OrderService
+		target  string
+		wantErr error
+	}{
+		{name: "пункт не выбран", s: s, target: "msk-2", wantErr: ErrPointNotSelected},
+		{name: "тот же пункт", s: source, target: "msk-1", wantErr: ErrSamePoint},
+		{name: "неизвестный пункт", s: source, target: "spb-1", wantErr: ErrUnknownPoint},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.s.TransferOrder(1, tt.target); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ошибка = %v, ожидалась %v", err, tt.wantErr)
+			}
+		})
+	}
+}
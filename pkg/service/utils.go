@@ -1,10 +1,8 @@
 package service
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"os"
+	"strings"
 	"time"
 
 	"gitlab.ozon.dev/gojhw1/pkg/model"
@@ -17,34 +15,24 @@ type orderFileData struct {
 	Weight      float64 `json:"weight"`
 	Cost        float64 `json:"cost"`
 	PackageType string  `json:"package_type,omitempty"`
-	Wrapper     string  `json:"wrapper,omitempty"`
+	// Wrapper - обертки через "+", как в accept_order: "film+bubble"
+	Wrapper  string   `json:"wrapper,omitempty"`
+	Wrappers []string `json:"wrappers,omitempty"`
+	// Dimensions - габариты заказа в см, в CSV - "30x20x10"
+	Dimensions *model.Dimensions `json:"dimensions,omitempty"`
+	Fragile    bool              `json:"fragile,omitempty"`
+	PromoCode  string            `json:"promo_code,omitempty"`
 }
 
-// readOrdersFromFile читает и парсит JSON файл с заказами
-func readOrdersFromFile(filename string) ([]orderFileData, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrOpenFile, err)
-	}
-	defer file.Close()
-
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrReadFile, err)
-	}
-
-	var orders []orderFileData
-	if err = json.Unmarshal(data, &orders); err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrParseFile, err)
-	}
-
-	return orders, nil
+// acceptOptions - необязательные параметры заказа из файла
+func (d orderFileData) acceptOptions() AcceptOptions {
+	return AcceptOptions{Dimensions: d.Dimensions, Fragile: d.Fragile, PromoCode: d.PromoCode}
 }
 
-// parseDeadline парсит дедлайн из строки
-func parseDeadline(deadlineStr string) (time.Time, error) {
+// parseDeadline парсит дедлайн из строки. Длительность отсчитывается от now
+func parseDeadline(deadlineStr string, now time.Time) (time.Time, error) {
 	if dur, err := time.ParseDuration(deadlineStr); err == nil {
-		return time.Now().Add(dur), nil
+		return now.Add(dur), nil
 	}
 
 	deadline, err := time.Parse(timeLayout, deadlineStr)
@@ -55,20 +43,22 @@ func parseDeadline(deadlineStr string) (time.Time, error) {
 	return deadline, nil
 }
 
-// processPackaging обрабатывает параметры упаковки
-func processPackaging(packagemodeltr, wrapperStr string) (*model.PackageType, *model.WrapperType) {
+// processPackaging обрабатывает параметры упаковки. Обертки из wrapperStr идут перед обертками из wrappers
+func processPackaging(packagemodeltr, wrapperStr string, wrappers []string) (*model.PackageType, []model.WrapperType) {
 	var packageType *model.PackageType
-	var wrapper *model.WrapperType
 
 	if packagemodeltr != "" {
 		pt := model.PackageType(packagemodeltr)
 		packageType = &pt
 	}
 
+	var result []model.WrapperType
 	if wrapperStr != "" {
-		wt := model.WrapperType(wrapperStr)
-		wrapper = &wt
+		wrappers = append(strings.Split(wrapperStr, "+"), wrappers...)
+	}
+	for _, wrapper := range wrappers {
+		result = append(result, model.WrapperType(strings.TrimSpace(wrapper)))
 	}
 
-	return packageType, wrapper
+	return packageType, result
 }
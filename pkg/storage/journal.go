@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+const (
+	journalOpPut    = "put"
+	journalOpDelete = "delete"
+)
+
+// journalEntry - одна запись журнала операций
+type journalEntry struct {
+	Op    string       `json:"op"`
+	ID    int64        `json:"id"`
+	Order *OrderRecord `json:"order,omitempty"`
+}
+
+// diffOrders - вычисляет записи журнала, переводящие состояние prev в next
+func diffOrders(prev, next map[int64]model.Order) []journalEntry {
+	var entries []journalEntry
+	for id, order := range next {
+		if old, ok := prev[id]; ok && ordersEqual(old, order) {
+			continue
+		}
+		record := NewOrderRecord(order)
+		entries = append(entries, journalEntry{Op: journalOpPut, ID: id, Order: &record})
+	}
+	for id := range prev {
+		if _, ok := next[id]; !ok {
+			entries = append(entries, journalEntry{Op: journalOpDelete, ID: id})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+
+	return entries
+}
+
+// ordersEqual - сравнивает заказы по значениям полей
+func ordersEqual(a, b model.Order) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// appendJournal - дописывает записи в конец журнала и сбрасывает их на диск
+func appendJournal(path string, entries []journalEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrJournalWrite, err)
+	}
+	defer file.Close()
+
+	if err = writeJournalEntries(file, entries); err != nil {
+		return err
+	}
+
+	if err = file.Sync(); err != nil {
+		return fmt.Errorf("%w: %w", ErrJournalWrite, err)
+	}
+
+	return nil
+}
+
+// rewriteJournal - атомарно заменяет журнал указанными записями
+func rewriteJournal(path string, entries []journalEntry) error {
+	return WriteFileAtomic(path, func(w io.Writer) error {
+		return writeJournalEntries(w, entries)
+	})
+}
+
+func writeJournalEntries(w io.Writer, entries []journalEntry) error {
+	buf := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buf)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("%w: %w", ErrJournalWrite, err)
+		}
+	}
+
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("%w: %w", ErrJournalWrite, err)
+	}
+
+	return nil
+}
+
+// readJournal - читает журнал. Недописанная последняя строка (обрыв записи при сбое) отбрасывается,
+// о чем сообщает флаг torn
+func readJournal(path string) (entries []journalEntry, torn bool, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return nil, false, readErr
+		}
+		last := errors.Is(readErr, io.EOF)
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			var entry journalEntry
+			if err = json.Unmarshal(line, &entry); err != nil {
+				if last {
+					return entries, true, nil
+				}
+				return nil, false, fmt.Errorf("%w: %w", ErrJournalCorrupted, err)
+			}
+			entries = append(entries, entry)
+		}
+
+		if last {
+			return entries, false, nil
+		}
+	}
+}
+
+// replayJournal - применяет записи журнала к снимку
+func replayJournal(orders map[int64]model.Order, entries []journalEntry) {
+	for _, entry := range entries {
+		switch entry.Op {
+		case journalOpPut:
+			if entry.Order != nil {
+				orders[entry.ID] = entry.Order.ToOrder()
+			}
+		case journalOpDelete:
+			delete(orders, entry.ID)
+		}
+	}
+}
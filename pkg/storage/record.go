@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"encoding/json"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+// OrderRecord - заказ в том виде, в котором его сохраняют хранилища. Хеш кода выдачи не выводится
+// пользователям (json:"-" в model.Order), поэтому запись сохраняет его в отдельном поле
+type OrderRecord struct {
+	model.Order
+	PickupCodeHash string `json:"pickup_code_hash,omitempty"`
+}
+
+// NewOrderRecord - возвращает запись для сохранения заказа
+func NewOrderRecord(order model.Order) OrderRecord {
+	return OrderRecord{Order: order, PickupCodeHash: order.PickupCodeHash}
+}
+
+// ToOrder - возвращает сохраненный заказ вместе с хешем кода выдачи
+func (r OrderRecord) ToOrder() model.Order {
+	order := r.Order
+	order.PickupCodeHash = r.PickupCodeHash
+
+	return order
+}
+
+// UnmarshalJSON - разбирает запись. Без него json вызвал бы model.Order.UnmarshalJSON
+// для всей записи, и хеш кода выдачи был бы потерян
+func (r *OrderRecord) UnmarshalJSON(data []byte) error {
+	if err := r.Order.UnmarshalJSON(data); err != nil {
+		return err
+	}
+
+	var secret struct {
+		PickupCodeHash string `json:"pickup_code_hash"`
+	}
+	if err := json.Unmarshal(data, &secret); err != nil {
+		return err
+	}
+	r.PickupCodeHash = secret.PickupCodeHash
+
+	return nil
+}
+
+// MarshalOrder - сериализует заказ для хранения, см. OrderRecord
+func MarshalOrder(order model.Order) ([]byte, error) {
+	return json.Marshal(NewOrderRecord(order))
+}
+
+// UnmarshalOrder - разбирает заказ, сохраненный MarshalOrder
+func UnmarshalOrder(data []byte) (model.Order, error) {
+	var record OrderRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return model.Order{}, err
+	}
+
+	return record.ToOrder(), nil
+}
+
+// newOrderRecords - возвращает записи для сохранения заказов
+func newOrderRecords(orders map[int64]model.Order) map[int64]OrderRecord {
+	records := make(map[int64]OrderRecord, len(orders))
+	for id, order := range orders {
+		records[id] = NewOrderRecord(order)
+	}
+
+	return records
+}
+
+// recordsToOrders - возвращает сохраненные заказы
+func recordsToOrders(records map[int64]OrderRecord) map[int64]model.Order {
+	orders := make(map[int64]model.Order, len(records))
+	for id, record := range records {
+		orders[id] = record.ToOrder()
+	}
+
+	return orders
+}
@@ -2,42 +2,250 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sync"
 
 	"gitlab.ozon.dev/gojhw1/pkg/model"
 )
 
+var (
+	ErrSnapshotCorrupted = errors.New("файл хранилища поврежден")
+	ErrJournalCorrupted  = errors.New("журнал операций поврежден")
+	ErrJournalWrite      = errors.New("ошибка записи журнала операций")
+)
+
+const (
+	backupSuffix  = ".bak"
+	journalSuffix = ".journal"
+	tempSuffix    = ".tmp"
+)
+
+// defaultSnapshotEvery - наименьшее число записей журнала, после которого снимок базы переписывается целиком
+const defaultSnapshotEvery = 1000
+
 type OrderStorage interface {
 	Save(map[int64]model.Order) error
 	Load() (map[int64]model.Order, error)
+	// Put - сохраняет добавленные или измененные заказы, не сравнивая всю базу
+	Put(orders []model.Order) error
 }
 
+// JSONStorage - хранилище заказов в JSON файле: снимок базы и журнал изменений (.journal)
 type JSONStorage struct {
 	FilePath string
+
+	mu   sync.Mutex
+	last map[int64]model.Order
+	// pending - число записей журнала, еще не вошедших в основной снимок
+	pending int
+	// snapshotSize - число заказов в основном снимке
+	snapshotSize  int
+	snapshotEvery int
 }
 
 // NewJSONStorage - создает новый экземпляр JSONStorage с указанным путем к файлу
 func NewJSONStorage(filePath string) *JSONStorage {
-	return &JSONStorage{FilePath: filePath}
+	return &JSONStorage{FilePath: filePath, snapshotEvery: defaultSnapshotEvery}
 }
 
-// Save - сохраняет заказы в JSON файл
+// Save - сохраняет заказы в JSON файл.
+// В журнал дописываются только изменившиеся заказы; снимок переписывается,
+// когда в журнале накопилось достаточно записей.
 func (s *JSONStorage) Save(orders map[int64]model.Order) error {
-	bytes, err := json.MarshalIndent(orders, "", "  ")
-	if err != nil {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.commit(diffOrders(s.last, orders))
+}
+
+// Put - дописывает в журнал переданные заказы. В отличие от Save, не сравнивает всю базу,
+// поэтому подходит для частого сохранения небольших порций при приеме больших файлов
+func (s *JSONStorage) Put(orders []model.Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]journalEntry, 0, len(orders))
+	for _, order := range orders {
+		record := NewOrderRecord(order)
+		entries = append(entries, journalEntry{Op: journalOpPut, ID: order.ID, Order: &record})
+	}
+
+	return s.commit(entries)
+}
+
+// commit - дописывает записи в журнал и переписывает снимок, когда записей в журнале не меньше snapshotEvery
+// и не меньше, чем заказов в снимке. Так объем перезаписи снимков растет линейно с числом записей журнала
+func (s *JSONStorage) commit(entries []journalEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if err := appendJournal(s.journalPath(), entries); err != nil {
 		return err
 	}
+	if s.last == nil {
+		s.last = make(map[int64]model.Order)
+	}
+	replayJournal(s.last, entries)
+	s.pending += len(entries)
+
+	if s.pending < max(s.snapshotEvery, s.snapshotSize, 1) {
+		return nil
+	}
 
-	return os.WriteFile(s.FilePath, bytes, 0644)
+	return s.checkpoint()
 }
 
-// Load - загружает заказы из JSON файла
+// checkpoint - переписывает снимок по текущему состоянию и начинает новый журнал.
+// Записи прежнего журнала уже вошли в новый снимок, он сохраняется как резервный
+// и вместе с резервной копией снимка восстанавливает основной снимок
+func (s *JSONStorage) checkpoint() error {
+	if err := s.writeSnapshot(s.last, true); err != nil {
+		return err
+	}
+
+	if err := os.Rename(s.journalPath(), s.backupJournalPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("%w: %w", ErrJournalWrite, err)
+	}
+	if err := syncDir(filepath.Dir(s.FilePath)); err != nil {
+		return err
+	}
+	s.pending = 0
+	s.snapshotSize = len(s.last)
+
+	return nil
+}
+
+// Load - загружает заказы из JSON файла.
+// Если основной файл поврежден или отсутствует, используется резервная копия вместе с резервным журналом.
+// Поверх снимка применяется журнал операций.
 func (s *JSONStorage) Load() (map[int64]model.Order, error) {
-	file, err := os.Open(s.FilePath)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orders, fromBackup, err := s.loadSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	s.snapshotSize = len(orders)
+
+	if fromBackup {
+		// резервный журнал переводит резервную копию в утерянный основной снимок
+		backlog, _, err := readJournal(s.backupJournalPath())
+		if err != nil {
+			return nil, err
+		}
+		replayJournal(orders, backlog)
+	}
+
+	entries, torn, err := readJournal(s.journalPath())
+	if err != nil {
+		return nil, err
+	}
+	replayJournal(orders, entries)
+
+	if err = s.repair(orders, entries, fromBackup, torn); err != nil {
+		return nil, err
+	}
+
+	s.last = copyOrders(orders)
+	s.pending = len(entries)
+
+	return orders, nil
+}
+
+// repair - перезаписывает основной снимок и журнал после восстановления
+func (s *JSONStorage) repair(orders map[int64]model.Order, entries []journalEntry, rewriteSnapshot, torn bool) error {
+	if rewriteSnapshot {
+		if err := s.writeSnapshot(orders, false); err != nil {
+			return fmt.Errorf("ошибка восстановления хранилища: %w", err)
+		}
+	}
+	if torn {
+		if err := rewriteJournal(s.journalPath(), entries); err != nil {
+			return fmt.Errorf("ошибка восстановления журнала: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// loadSnapshot - читает основной снимок, при ошибке - резервную копию
+func (s *JSONStorage) loadSnapshot() (map[int64]model.Order, bool, error) {
+	orders, err := readSnapshot(s.FilePath)
+	if err == nil && orders != nil {
+		return orders, false, nil
+	}
+
+	backup, backupErr := readSnapshot(s.backupPath())
+	if backupErr != nil {
+		if err != nil {
+			return nil, false, err
+		}
+		return nil, false, backupErr
+	}
+	if backup != nil {
+		return backup, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return make(map[int64]model.Order), false, nil
+}
+
+// writeSnapshot - записывает снимок во временный файл, сбрасывает его на диск и переименовывает в основной.
+// При rotate прежний основной файл сохраняется как резервная копия
+func (s *JSONStorage) writeSnapshot(orders map[int64]model.Order, rotate bool) error {
+	bytes, err := json.MarshalIndent(newOrderRecords(orders), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := s.FilePath + tempSuffix
+	if err = writeFileSynced(tmpPath, func(w io.Writer) error {
+		_, err := w.Write(bytes)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if rotate {
+		if err = os.Rename(s.FilePath, s.backupPath()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if err = os.Rename(tmpPath, s.FilePath); err != nil {
+		return err
+	}
+
+	return syncDir(filepath.Dir(s.FilePath))
+}
+
+func (s *JSONStorage) backupPath() string {
+	return s.FilePath + backupSuffix
+}
+
+func (s *JSONStorage) journalPath() string {
+	return s.FilePath + journalSuffix
+}
+
+func (s *JSONStorage) backupJournalPath() string {
+	return s.journalPath() + backupSuffix
+}
+
+// readSnapshot - читает снимок. Для отсутствующего файла возвращает nil без ошибки,
+// для пустого - пустую карту
+func readSnapshot(path string) (map[int64]model.Order, error) {
+	file, err := os.Open(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return make(map[int64]model.Order), nil
+			return nil, nil
 		}
 		return nil, err
 	}
@@ -52,11 +260,69 @@ func (s *JSONStorage) Load() (map[int64]model.Order, error) {
 		return make(map[int64]model.Order), nil
 	}
 
-	var ordersMap map[int64]model.Order
-	err = json.Unmarshal(data, &ordersMap)
+	var records map[int64]OrderRecord
+	if err = json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrSnapshotCorrupted, path, err)
+	}
+
+	return recordsToOrders(records), nil
+}
+
+// WriteFileAtomic - записывает файл через временный файл с последующим переименованием.
+// Файл и запись каталога сбрасываются на диск, поэтому после сбоя остается либо прежнее, либо новое содержимое
+func WriteFileAtomic(path string, write func(w io.Writer) error) error {
+	tmpPath := path + tempSuffix
+	if err := writeFileSynced(tmpPath, write); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// writeFileSynced - записывает файл и сбрасывает его содержимое на диск
+func writeFileSynced(path string, write func(w io.Writer) error) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	if err = write(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// syncDir - сбрасывает на диск запись каталога, чтобы переименование пережило сбой питания
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	if err = d.Sync(); err != nil && !errors.Is(err, os.ErrInvalid) {
+		return err
+	}
+
+	return nil
+}
+
+func copyOrders(orders map[int64]model.Order) map[int64]model.Order {
+	result := make(map[int64]model.Order, len(orders))
+	for k, v := range orders {
+		result[k] = v
 	}
 
-	return ordersMap, nil
+	return result
 }
@@ -0,0 +1,308 @@
+package storage
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.ozon.dev/gojhw1/pkg/model"
+)
+
+var testTime = time.Date(2030, 2, 20, 15, 4, 5, 0, time.UTC)
+
+func testOrder(id int64, state model.OrderState) model.Order {
+	return model.Order{
+		ID:         id,
+		CustomerID: 1,
+		State:      state,
+		Weight:     1.5,
+		Cost:       100,
+		DeadlineAt: testTime.Add(48 * time.Hour),
+		UpdatedAt:  testTime,
+	}
+}
+
+func newTestStorage(t *testing.T, snapshotEvery int) *JSONStorage {
+	t.Helper()
+
+	s := NewJSONStorage(filepath.Join(t.TempDir(), "storage.json"))
+	s.snapshotEvery = snapshotEvery
+
+	return s
+}
+
+// saveSteps - сохраняет последовательность состояний базы и возвращает последнее
+func saveSteps(t *testing.T, s *JSONStorage, steps []map[int64]model.Order) map[int64]model.Order {
+	t.Helper()
+
+	var last map[int64]model.Order
+	for i, step := range steps {
+		if err := s.Save(step); err != nil {
+			t.Fatalf("Save #%d: %v", i, err)
+		}
+		last = step
+	}
+
+	return last
+}
+
+// testSteps - прием двух заказов, выдача первого, удаление и повторный прием второго.
+// Каждый шаг дает одну запись журнала
+func testSteps() []map[int64]model.Order {
+	return []map[int64]model.Order{
+		{1: testOrder(1, model.StateAccepted)},
+		{1: testOrder(1, model.StateAccepted), 2: testOrder(2, model.StateAccepted)},
+		{1: testOrder(1, model.StateDelivered), 2: testOrder(2, model.StateAccepted)},
+		{1: testOrder(1, model.StateDelivered)},
+		{1: testOrder(1, model.StateDelivered), 2: testOrder(2, model.StateReturned)},
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	n := 0
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		n++
+	}
+
+	return n
+}
+
+func TestJSONStorageSaveLoad(t *testing.T) {
+	tests := []struct {
+		name          string
+		snapshotEvery int
+		wantSnapshot  bool
+		wantJournal   int
+		wantBackup    int
+	}{
+		{name: "только журнал", snapshotEvery: 100, wantSnapshot: false, wantJournal: 5, wantBackup: 0},
+		{name: "снимок после каждых 2 записей", snapshotEvery: 2, wantSnapshot: true, wantJournal: 1, wantBackup: 2},
+		{name: "снимок после каждой записи", snapshotEvery: 1, wantSnapshot: true, wantJournal: 0, wantBackup: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t, tt.snapshotEvery)
+			want := saveSteps(t, s, testSteps())
+
+			_, err := os.Stat(s.FilePath)
+			if gotSnapshot := err == nil; gotSnapshot != tt.wantSnapshot {
+				t.Errorf("снимок существует = %v, ожидалось %v", gotSnapshot, tt.wantSnapshot)
+			}
+			if got := countLines(t, s.journalPath()); got != tt.wantJournal {
+				t.Errorf("записей в журнале = %d, ожидалось %d", got, tt.wantJournal)
+			}
+			if got := countLines(t, s.backupJournalPath()); got != tt.wantBackup {
+				t.Errorf("записей в резервном журнале = %d, ожидалось %d", got, tt.wantBackup)
+			}
+
+			got, err := NewJSONStorage(s.FilePath).Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Load = %v, ожидалось %v", got, want)
+			}
+		})
+	}
+}
+
+func TestJSONStorageSkipsUnchangedOrders(t *testing.T) {
+	s := newTestStorage(t, 100)
+	orders := map[int64]model.Order{1: testOrder(1, model.StateAccepted), 2: testOrder(2, model.StateAccepted)}
+
+	for range 3 {
+		if err := s.Save(orders); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := countLines(t, s.journalPath()); got != 2 {
+		t.Errorf("записей в журнале = %d, ожидалось 2", got)
+	}
+}
+
+func TestJSONStoragePut(t *testing.T) {
+	const total = 100
+
+	s := newTestStorage(t, 1)
+	all := make(map[int64]model.Order, total)
+	checkpoints := 0
+	for id := int64(1); id <= total; id++ {
+		order := testOrder(id, model.StateAccepted)
+		if err := s.Put([]model.Order{order}); err != nil {
+			t.Fatal(err)
+		}
+		all[id] = order
+
+		if countLines(t, s.journalPath()) == 0 {
+			checkpoints++
+		}
+	}
+
+	// снимок переписывается, когда журнал догоняет снимок, то есть при росте базы вдвое
+	if checkpoints > 8 {
+		t.Errorf("снимок переписан %d раз для %d заказов", checkpoints, total)
+	}
+
+	// Save после Put не дописывает в журнал уже сохраненные заказы
+	before := countLines(t, s.journalPath())
+	if err := s.Save(all); err != nil {
+		t.Fatal(err)
+	}
+	if got := countLines(t, s.journalPath()); got != before {
+		t.Errorf("записей в журнале после Save = %d, ожидалось %d", got, before)
+	}
+
+	got, err := NewJSONStorage(s.FilePath).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, all) {
+		t.Errorf("Load вернул %d заказов, ожидалось %d", len(got), len(all))
+	}
+}
+
+func TestJSONStorageKeepsPickupCodeHash(t *testing.T) {
+	tests := []struct {
+		name          string
+		snapshotEvery int
+		wantJournal   int
+	}{
+		{name: "журнал", snapshotEvery: 100, wantJournal: 1},
+		{name: "снимок", snapshotEvery: 1, wantJournal: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t, tt.snapshotEvery)
+			order := testOrder(1, model.StateAccepted)
+			order.PickupCodeHash = "0011223344556677$deadbeef"
+			if err := s.Save(map[int64]model.Order{1: order}); err != nil {
+				t.Fatal(err)
+			}
+			if got := countLines(t, s.journalPath()); got != tt.wantJournal {
+				t.Fatalf("записей в журнале %d, ожидалось %d", got, tt.wantJournal)
+			}
+
+			// хеш не выводится пользователям, но хранилище его сохраняет
+			got, err := NewJSONStorage(s.FilePath).Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got[1].PickupCodeHash != order.PickupCodeHash {
+				t.Errorf("хеш кода выдачи %q, ожидался %q", got[1].PickupCodeHash, order.PickupCodeHash)
+			}
+		})
+	}
+}
+
+func TestJSONStorageRecovery(t *testing.T) {
+	tests := []struct {
+		name string
+		// damage - имитирует сбой или повреждение файлов хранилища
+		damage  func(t *testing.T, s *JSONStorage)
+		wantErr error
+	}{
+		{
+			name:   "без повреждений",
+			damage: func(*testing.T, *JSONStorage) {},
+		},
+		{
+			name: "недописанная строка журнала",
+			damage: func(t *testing.T, s *JSONStorage) {
+				appendFile(t, s.journalPath(), `{"op":"put","id":3,"order":{"id":`)
+			},
+		},
+		{
+			name: "поврежденный основной снимок",
+			damage: func(t *testing.T, s *JSONStorage) {
+				writeFile(t, s.FilePath, `{"1": {"id": 1, "sta`)
+			},
+		},
+		{
+			name: "основной снимок отсутствует",
+			damage: func(t *testing.T, s *JSONStorage) {
+				if err := os.Remove(s.FilePath); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "повреждена середина журнала",
+			damage: func(t *testing.T, s *JSONStorage) {
+				writeFile(t, s.journalPath(), "not json\n"+`{"op":"delete","id":2}`+"\n")
+			},
+			wantErr: ErrJournalCorrupted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t, 2)
+			want := saveSteps(t, s, testSteps())
+			tt.damage(t, s)
+
+			recovered := NewJSONStorage(s.FilePath)
+			got, err := recovered.Load()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load ошибка = %v, ожидалась %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("Load = %v, ожидалось %v", got, want)
+			}
+
+			// после восстановления база снова читается без ошибок и с тем же содержимым
+			again, err := NewJSONStorage(s.FilePath).Load()
+			if err != nil {
+				t.Fatalf("повторный Load: %v", err)
+			}
+			if !reflect.DeepEqual(again, want) {
+				t.Errorf("повторный Load = %v, ожидалось %v", again, want)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err = file.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}